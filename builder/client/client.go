@@ -3,6 +3,8 @@ package client
 import (
 	"time"
 
+	"github.com/bluekaki/vv/builder/journal"
+	"github.com/bluekaki/vv/builder/tracing"
 	"github.com/bluekaki/vv/internal/configs"
 	"github.com/bluekaki/vv/internal/interceptor"
 
@@ -38,6 +40,11 @@ type option struct {
 	resolverBuilder resolver.Builder
 	dialTimeout     time.Duration
 	sign            Sign
+	signStreamMsg   bool
+	traceExporter   tracing.Exporter
+	journalSink     journal.Sink
+	journalOnError  func(journalID string, err error)
+	journalHashKey  []byte
 }
 
 // WithCredential setup credential for tls
@@ -75,6 +82,44 @@ func WithSign(sign Sign) Option {
 	}
 }
 
+// WithStreamMessageSign every outbound message of stream will be signed by the signature handler (WithSign required)
+func WithStreamMessageSign() Option {
+	return func(opt *option) {
+		opt.signStreamMsg = true
+	}
+}
+
+// WithTracing a span created for every rpc, child of the span in context, and propagated by traceparent
+func WithTracing(exporter tracing.Exporter) Option {
+	return func(opt *option) {
+		opt.traceExporter = exporter
+	}
+}
+
+// WithJournal journal the outbound calls of method which options.journal is true, sampled by options.journal_sampling
+// & options.journal_slow_threshold the same as server, along with the target, attempts and the server's journal id;
+// authorization and proxy-authorization redacted. See WithJournalErrorHandler.
+func WithJournal(sink journal.Sink) Option {
+	return func(opt *option) {
+		opt.journalSink = sink
+	}
+}
+
+// WithJournalErrorHandler the error of writing journal reported to handler, journal.ErrDropped at most once per 10s
+func WithJournalErrorHandler(handler func(journalID string, err error)) Option {
+	return func(opt *option) {
+		opt.journalOnError = handler
+	}
+}
+
+// WithJournalHashKey the key of HMAC-SHA256 hashing the sensitive fields (options.sensitive HASH) in journal,
+// the same as server.WithJournalHashKey to correlate the hashes, default a random one per client
+func WithJournalHashKey(key []byte) Option {
+	return func(opt *option) {
+		opt.journalHashKey = append([]byte{}, key...)
+	}
+}
+
 // New create a grpc client conn
 func New(endpoint string, options ...Option) (*grpc.ClientConn, error) {
 	if endpoint == "" {
@@ -101,7 +146,17 @@ func New(endpoint string, options ...Option) (*grpc.ClientConn, error) {
 		dialTimeout = opt.dialTimeout
 	}
 
-	clientInterceptor := interceptor.NewClientInterceptor(opt.sign)
+	var journalConfig *interceptor.JournalConfig
+	if opt.journalSink != nil {
+		journalConfig = &interceptor.JournalConfig{
+			Sink:             opt.journalSink,
+			RedactedMetadata: []string{interceptor.Authorization, interceptor.ProxyAuthorization},
+			OnError:          opt.journalOnError,
+			HashKey:          opt.journalHashKey,
+		}
+	}
+
+	clientInterceptor := interceptor.NewClientInterceptor(opt.sign, opt.signStreamMsg, opt.traceExporter, journalConfig)
 
 	dialOptions := []grpc.DialOption{
 		grpc.WithResolvers(resolverBuilder),
@@ -113,6 +168,10 @@ func New(endpoint string, options ...Option) (*grpc.ClientConn, error) {
 		grpc.WithDefaultServiceConfig(configs.ServiceConfig),
 	}
 
+	if statsHandler := clientInterceptor.StatsHandler(); statsHandler != nil {
+		dialOptions = append(dialOptions, grpc.WithStatsHandler(statsHandler))
+	}
+
 	if opt.credential == nil {
 		dialOptions = append(dialOptions, grpc.WithInsecure())
 	} else {
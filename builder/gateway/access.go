@@ -0,0 +1,225 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bluekaki/vv/builder/journal"
+	"github.com/bluekaki/vv/internal/interceptor"
+	"github.com/bluekaki/vv/internal/protos/gen"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// accessJournaled mark the *accessRoute of request in context
+type accessJournaled struct{}
+
+// accessRoute filled by annotator once the request routed to a rpc method
+type accessRoute struct {
+	fullMethod string
+}
+
+// routed record the rpc method of request, if access journaled
+func routed(ctx context.Context, req *http.Request) {
+	route, ok := req.Context().Value(accessJournaled{}).(*accessRoute)
+	if !ok {
+		return
+	}
+
+	if fullMethod, ok := runtime.RPCMethod(ctx); ok {
+		route.fullMethod = fullMethod
+	}
+}
+
+// routePattern the path of method's google.api.http binding which matches verb
+func routePattern(fullMethod, verb string) string {
+	rule, _ := proto.GetExtension(interceptor.FileDescriptor.LookupOptions(fullMethod), annotations.E_Http).(*annotations.HttpRule)
+	if rule == nil {
+		return ""
+	}
+
+	for _, binding := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+		if method, path := httpPattern(binding); method == verb {
+			return path
+		}
+	}
+
+	_, path := httpPattern(rule)
+	return path
+}
+
+// AccessOption how setup the access journal
+type AccessOption func(*accessOption)
+
+type accessOption struct {
+	trustedProxies []*net.IPNet
+	onError        func(journalID string, err error)
+}
+
+// WithTrustedProxies the X-Forwarded-For appended by the proxies (IP or CIDR) trusted, so that the client ip is the
+// right-most untrusted one of it; otherwise the client ip is the remote address, as X-Forwarded-For is spoofable.
+func WithTrustedProxies(proxies ...string) AccessOption {
+	return func(opt *accessOption) {
+		for _, proxy := range proxies {
+			if !strings.Contains(proxy, "/") {
+				if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+					proxy += "/32"
+				} else {
+					proxy += "/128"
+				}
+			}
+
+			_, network, err := net.ParseCIDR(proxy)
+			if err != nil {
+				panic(errors.Wrapf(err, "trusted proxy %s illegal", proxy))
+			}
+			opt.trustedProxies = append(opt.trustedProxies, network)
+		}
+	}
+}
+
+// WithAccessErrorHandler the error of writing journal reported to handler, journal.ErrDropped at most once per 10s
+func WithAccessErrorHandler(handler func(journalID string, err error)) AccessOption {
+	return func(opt *accessOption) {
+		opt.onError = handler
+	}
+}
+
+func (a *accessOption) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, network := range a.trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP the remote address, or the right-most untrusted one of X-Forwarded-For if the remote address is a trusted proxy
+func (a *accessOption) clientIP(req *http.Request) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		ip = req.RemoteAddr
+	}
+
+	if !a.trusted(ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+
+		ip = hop
+		if !a.trusted(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// AccessJournal journal every http request served by handler (the mux of New), including the ones failed in gateway,
+// e.g. unknown routes, malformed json and dial timeouts to server, which never reach the server's journal.
+func AccessJournal(handler http.Handler, sink journal.Sink, options ...AccessOption) http.Handler {
+	opt := new(accessOption)
+	for _, f := range options {
+		f(opt)
+	}
+
+	write := interceptor.NewJournalWriter(sink, opt.onError)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ts := time.Now()
+		route := new(accessRoute)
+		writer := &accessResponseWriter{ResponseWriter: w}
+
+		handler.ServeHTTP(writer, req.WithContext(context.WithValue(req.Context(), accessJournaled{}, route)))
+
+		status := writer.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		access := &pb.Access{
+			Method:           req.Method,
+			Uri:              req.RequestURI,
+			Status:           int32(status),
+			ResponseBytes:    writer.size,
+			ClientIp:         opt.clientIP(req),
+			BackendJournalId: w.Header().Get(interceptor.JournalID), // Grpc-Metadata- trimmed by the outgoing header matcher
+		}
+		if route.fullMethod != "" {
+			access.Route = routePattern(route.fullMethod, req.Method)
+		}
+
+		write(&pb.Journal{
+			Id:          interceptor.NewJournalID(),
+			Success:     status < http.StatusBadRequest,
+			CostSeconds: time.Since(ts).Seconds(),
+			Ts:          timestamppb.New(ts),
+			Access:      access,
+		})
+	})
+}
+
+var _ http.ResponseWriter = (*accessResponseWriter)(nil)
+var _ http.Flusher = (*accessResponseWriter)(nil)
+var _ http.Hijacker = (*accessResponseWriter)(nil)
+
+// accessResponseWriter record status & size, flushable for server-streaming and hijackable for websocket
+type accessResponseWriter struct {
+	http.ResponseWriter
+	status int
+	size   uint64
+}
+
+func (a *accessResponseWriter) WriteHeader(statusCode int) {
+	if a.status == 0 {
+		a.status = statusCode
+	}
+	a.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (a *accessResponseWriter) Write(p []byte) (int, error) {
+	if a.status == 0 {
+		a.status = http.StatusOK
+	}
+
+	n, err := a.ResponseWriter.Write(p)
+	a.size += uint64(n)
+	return n, err
+}
+
+func (a *accessResponseWriter) Flush() {
+	if flusher, ok := a.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (a *accessResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := a.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijack not supported")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && a.status == 0 {
+		a.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
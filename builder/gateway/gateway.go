@@ -3,10 +3,13 @@ package gateway
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/bluekaki/vv/builder/tracing"
 	"github.com/bluekaki/vv/internal/configs"
 	"github.com/bluekaki/vv/internal/interceptor"
 
@@ -16,7 +19,7 @@ import (
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/resolver/dns"
-	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 var (
@@ -37,9 +40,20 @@ func init() {
 type Option func(*option)
 
 type option struct {
-	credential  credentials.TransportCredentials
-	keepalive   *keepalive.ClientParameters
-	dialTimeout time.Duration
+	credential    credentials.TransportCredentials
+	keepalive     *keepalive.ClientParameters
+	dialTimeout   time.Duration
+	webSockets    []protoreflect.FileDescriptor
+	origins       []string
+	traceExporter tracing.Exporter
+	headers       []forwardedHeader
+	healthz       *healthz
+	healthzPath   string
+}
+
+type forwardedHeader struct {
+	key    string // metadata key, lower case
+	redact bool
 }
 
 // WithCredential setup credential for tls
@@ -63,7 +77,104 @@ func WithDialTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithWebSocket bridge client & bidi streaming methods of descriptor to websocket,
+// GET requests upgraded on the method's google.api.http path (non-GET with body, otherwise New panics),
+// every text frame is a protojson message. See WithWebSocketOrigins.
+func WithWebSocket(descriptor protoreflect.FileDescriptor) Option {
+	return func(opt *option) {
+		opt.webSockets = append(opt.webSockets, descriptor)
+	}
+}
+
+// WithWebSocketOrigins the cross-origin websocket allowed from origin(s), e.g. https://app.example.com or * for any;
+// only the same origin allowed by default.
+func WithWebSocketOrigins(origins ...string) Option {
+	return func(opt *option) {
+		opt.origins = append(opt.origins, origins...)
+	}
+}
+
+// WithTracing a span created for every forwarded request, child of the traceparent header
+func WithTracing(exporter tracing.Exporter) Option {
+	return func(opt *option) {
+		opt.traceExporter = exporter
+	}
+}
+
+// WithForwardedHeader forward the http header into metadata (lower case key), redact asks server to redact it in journal.
+// It's journaled only if it's in the journal metadata of server, see server.WithJournalMetadata.
+// New panics if the header is reserved, e.g. grpc-*, authorization, traceparent, see reservedHeader.
+func WithForwardedHeader(header string, redact bool) Option {
+	return func(opt *option) {
+		key := strings.ToLower(strings.TrimSpace(header))
+		if reason := reservedHeader(key); reason != "" {
+			panic(fmt.Sprintf("forwarded header [%s] %s", header, reason))
+		}
+
+		opt.headers = append(opt.headers, forwardedHeader{key: key, redact: redact})
+	}
+}
+
+// reservedHeaders the metadata set by gateway (or grpc), which the forwarded header must not overwrite
+var reservedHeaders = map[string]bool{
+	interceptor.Authorization:      true,
+	interceptor.ProxyAuthorization: true,
+	interceptor.Date:               true,
+	interceptor.Method:             true,
+	interceptor.URI:                true,
+	interceptor.Body:               true,
+	interceptor.XForwardedFor:      true,
+	interceptor.XForwardedHost:     true,
+	interceptor.JournalID:          true,
+	interceptor.XJournalID:         true,
+	interceptor.RedactedKeys:       true,
+	tracing.Traceparent:            true,
+	tracing.Tracestate:             true,
+	"content-type":                 true,
+	"user-agent":                   true,
+	"te":                           true,
+}
+
+// reservedHeader the reason why key can't be forwarded, empty if it can
+func reservedHeader(key string) string {
+	switch {
+	case key == "":
+		return "empty"
+	case reservedHeaders[key]:
+		return "reserved by gateway"
+	case strings.HasPrefix(key, "grpc-"), strings.HasPrefix(key, ":"):
+		return "reserved by grpc"
+	case strings.HasPrefix(key, runtime.MetadataPrefix):
+		return "reserved by grpc-gateway"
+	case strings.HasSuffix(key, "-bin"):
+		return "binary metadata not supported"
+	}
+
+	return ""
+}
+
+// WithHealthz serve GET /healthz (see WithHealthzPath), 200 if the service(s) of the backend on endpoint are SERVING,
+// otherwise 503; no service checks the whole backend server. The connection to endpoint closed once ctx done,
+// the same as the generated RegisterXHandlerFromEndpoint.
+func WithHealthz(ctx context.Context, endpoint string, services ...string) Option {
+	return func(opt *option) {
+		opt.healthz = &healthz{ctx: ctx, endpoint: endpoint, services: services}
+	}
+}
+
+// WithHealthzPath the path of WithHealthz, default /healthz
+func WithHealthzPath(path string) Option {
+	return func(opt *option) {
+		opt.healthzPath = path
+	}
+}
+
 // New create grpc-gateway server mux, and grpc dial options.
+//
+// Server-streaming responses are framed by the request's Accept header:
+// MIMENewlineDelimitedJSON or MIMEEventStream, newline-delimited JSON by default;
+// unary responses and errors before streaming are plain JSON whatever the Accept header.
+// Wrap the mux by AccessJournal to journal every http request.
 func New(options ...Option) (*runtime.ServeMux, []grpc.DialOption) {
 	opt := new(option)
 	for _, f := range options {
@@ -83,24 +194,25 @@ func New(options ...Option) (*runtime.ServeMux, []grpc.DialOption) {
 	mux := runtime.NewServeMux(
 		runtime.WithIncomingHeaderMatcher(runtime.DefaultHeaderMatcher),
 		runtime.WithOutgoingHeaderMatcher(runtime.DefaultHeaderMatcher),
-		runtime.WithMetadata(annotator),
-		runtime.WithErrorHandler(runtime.DefaultHTTPErrorHandler),
+		runtime.WithMetadata(newAnnotator(opt.headers)),
+		runtime.WithErrorHandler(unframedHTTPError),
+		runtime.WithForwardResponseOption(unframedResponse),
 		runtime.WithStreamErrorHandler(runtime.DefaultStreamErrorHandler),
 		runtime.WithRoutingErrorHandler(runtime.DefaultRoutingErrorHandler),
 		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.HTTPBodyMarshaler{
-			Marshaler: &runtime.JSONPb{
-				MarshalOptions: protojson.MarshalOptions{
-					UseProtoNames:   true,
-					EmitUnpopulated: true,
-				},
-				UnmarshalOptions: protojson.UnmarshalOptions{
-					DiscardUnknown: true,
-				},
-			},
+			Marshaler: newJSONPb(),
 		}),
+		runtime.WithMarshalerOption(MIMENewlineDelimitedJSON, &ndjsonMarshaler{JSONPb: newJSONPb()}),
+		runtime.WithMarshalerOption(MIMEEventStream, &eventStreamMarshaler{JSONPb: newJSONPb()}),
 	)
 
-	gatewayInterceptor := interceptor.NewGatewayInterceptor()
+	for _, descriptor := range opt.webSockets {
+		if err := registerWebSocket(mux, descriptor, opt.origins); err != nil {
+			panic(err)
+		}
+	}
+
+	gatewayInterceptor := interceptor.NewGatewayInterceptor(opt.traceExporter)
 
 	dialOptions := []grpc.DialOption{
 		grpc.WithResolvers(dns.NewBuilder()),
@@ -108,6 +220,7 @@ func New(options ...Option) (*runtime.ServeMux, []grpc.DialOption) {
 		grpc.WithBlock(),
 		grpc.WithKeepaliveParams(*kacp),
 		grpc.WithUnaryInterceptor(gatewayInterceptor.UnaryInterceptor),
+		grpc.WithStreamInterceptor(gatewayInterceptor.StreamInterceptor),
 		grpc.WithDefaultServiceConfig(configs.ServiceConfig),
 	}
 
@@ -117,14 +230,53 @@ func New(options ...Option) (*runtime.ServeMux, []grpc.DialOption) {
 		dialOptions = append(dialOptions, grpc.WithTransportCredentials(opt.credential))
 	}
 
+	if opt.healthz != nil {
+		if opt.healthzPath != "" {
+			opt.healthz.path = opt.healthzPath
+		}
+
+		if err := registerHealthz(mux, opt.healthz, opt.credential, kacp); err != nil {
+			panic(err)
+		}
+	}
+
 	return mux, dialOptions
 }
 
+func newAnnotator(headers []forwardedHeader) func(ctx context.Context, req *http.Request) metadata.MD {
+	var redacted []string
+	for _, header := range headers {
+		if header.redact {
+			redacted = append(redacted, header.key)
+		}
+	}
+
+	return func(ctx context.Context, req *http.Request) metadata.MD {
+		routed(ctx, req)
+		meta := annotator(ctx, req)
+
+		for _, header := range headers {
+			if values := req.Header.Values(header.key); len(values) > 0 {
+				meta.Set(header.key, values...)
+			}
+		}
+
+		if len(redacted) > 0 {
+			meta.Set(interceptor.RedactedKeys, strings.Join(redacted, ","))
+		}
+
+		return meta
+	}
+}
+
 func annotator(ctx context.Context, req *http.Request) metadata.MD {
-	body, _ := ioutil.ReadAll(req.Body)
-	req.Body = ioutil.NopCloser(bytes.NewBuffer(body)) // re-construct req body
+	var body []byte
+	if !bridgedFromWebSocket(req.Context()) { // the body of websocket is endless
+		body, _ = ioutil.ReadAll(req.Body)
+		req.Body = ioutil.NopCloser(bytes.NewBuffer(body)) // re-construct req body
+	}
 
-	return metadata.Pairs(
+	meta := metadata.Pairs(
 		interceptor.Authorization, req.Header.Get("Authorization"),
 		interceptor.ProxyAuthorization, req.Header.Get("Proxy-Authorization"),
 		interceptor.Date, req.Header.Get("Date"),
@@ -134,4 +286,15 @@ func annotator(ctx context.Context, req *http.Request) metadata.MD {
 		interceptor.XForwardedFor, req.Header.Get("X-Forwarded-For"),
 		interceptor.XForwardedHost, req.Header.Get("X-Forwarded-Host"),
 	)
+
+	if traceparent := req.Header.Get(tracing.Traceparent); traceparent != "" {
+		meta.Set(tracing.Traceparent, traceparent)
+		meta.Set(tracing.Tracestate, req.Header.Values(tracing.Tracestate)...)
+	}
+
+	if journalID := req.Header.Get(interceptor.XJournalID); journalID != "" {
+		meta.Set(interceptor.JournalID, journalID) // honoured by server if trusted
+	}
+
+	return meta
 }
@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestWithForwardedHeader(t *testing.T) {
+	cases := []struct {
+		header string
+		key    string
+		panics bool
+	}{
+		{header: "X-Tenant-Id", key: "x-tenant-id"},
+		{header: " X-Request-Id ", key: "x-request-id"},
+		{header: "", panics: true},
+		{header: "Authorization", panics: true},
+		{header: "Proxy-Authorization", panics: true},
+		{header: "journal_id", panics: true},
+		{header: "X-Journal-Id", panics: true},
+		{header: "Traceparent", panics: true},
+		{header: "tracestate", panics: true},
+		{header: "vv-redacted", panics: true},
+		{header: "Body", panics: true},
+		{header: "Content-Type", panics: true},
+		{header: "Grpc-Timeout", panics: true},
+		{header: "Grpc-Metadata-Foo", panics: true},
+		{header: "grpcgateway-user-agent", panics: true},
+		{header: ":authority", panics: true},
+		{header: "X-Trace-Bin", panics: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.header, func(t *testing.T) {
+			opt := new(option)
+			err := func() (err error) {
+				defer func() {
+					if p := recover(); p != nil {
+						err = fmt.Errorf("%v", p)
+					}
+				}()
+
+				WithForwardedHeader(c.header, false)(opt)
+				return nil
+			}()
+
+			if (err != nil) != c.panics {
+				t.Fatalf("panics %v, want %v", err, c.panics)
+			}
+			if !c.panics && (len(opt.headers) != 1 || opt.headers[0].key != c.key) {
+				t.Errorf("headers %v, want %s", opt.headers, c.key)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/resolver/dns"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// healthzTimeout the backend checked at most
+	healthzTimeout = time.Second * 2
+	// defaultHealthzPath the default path of healthz
+	defaultHealthzPath = "/healthz"
+)
+
+type healthz struct {
+	ctx      context.Context // the conn closed once done
+	endpoint string
+	services []string
+	path     string // empty defaultHealthzPath
+}
+
+// registerHealthz the backend dialed lazily, so the gateway could start before it
+func registerHealthz(mux *runtime.ServeMux, healthz *healthz, credential credentials.TransportCredentials, kacp *keepalive.ClientParameters) error {
+	if healthz.ctx == nil {
+		return errors.New("healthz context required")
+	}
+	if healthz.endpoint == "" {
+		return errors.New("healthz endpoint required")
+	}
+
+	path := healthz.path
+	if path == "" {
+		path = defaultHealthzPath
+	}
+	if !strings.HasPrefix(path, "/") {
+		return errors.Errorf("healthz path %s must start with /", path)
+	}
+
+	dialOptions := []grpc.DialOption{
+		grpc.WithResolvers(dns.NewBuilder()),
+		grpc.WithKeepaliveParams(*kacp),
+	}
+	if credential == nil {
+		dialOptions = append(dialOptions, grpc.WithInsecure())
+	} else {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credential))
+	}
+
+	conn, err := grpc.Dial(healthz.endpoint, dialOptions...)
+	if err != nil {
+		return errors.Wrap(err, "dial healthz endpoint err")
+	}
+
+	go func() {
+		<-healthz.ctx.Done()
+		conn.Close()
+	}()
+
+	services := healthz.services
+	if len(services) == 0 {
+		services = []string{""} // the whole server
+	}
+
+	client := healthpb.NewHealthClient(conn)
+	return mux.HandlePath(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx, cancel := context.WithTimeout(r.Context(), healthzTimeout)
+		defer cancel()
+
+		for _, service := range services {
+			resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+			if err != nil {
+				http.Error(w, healthzMessage(service, status.Convert(err).Message()), http.StatusServiceUnavailable)
+				return
+			}
+			if resp.Status != healthpb.HealthCheckResponse_SERVING {
+				http.Error(w, healthzMessage(service, resp.Status.String()), http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		w.Write([]byte("ok"))
+	})
+}
+
+// healthzMessage the message prefixed by the service, the empty one means the whole server
+func healthzMessage(service, message string) string {
+	if service == "" {
+		return message
+	}
+	return service + ": " + message
+}
@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthz(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("vv.test.Up", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("vv.test.Down", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	cases := []struct {
+		name     string
+		services []string
+		path     string
+		code     int
+		body     string
+	}{
+		{name: "whole server", code: http.StatusOK, body: "ok"},
+		{name: "custom path", path: "/-/healthy", code: http.StatusOK, body: "ok"},
+		{name: "serving", services: []string{"vv.test.Up"}, code: http.StatusOK, body: "ok"},
+		{name: "not serving", services: []string{"vv.test.Up", "vv.test.Down"}, code: http.StatusServiceUnavailable, body: "vv.test.Down: NOT_SERVING"},
+		{name: "unknown", services: []string{"vv.test.Unknown"}, code: http.StatusServiceUnavailable, body: "vv.test.Unknown: unknown service"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			path := c.path
+			if path == "" {
+				path = defaultHealthzPath
+			}
+
+			mux := runtime.NewServeMux()
+			if err := registerHealthz(mux, &healthz{ctx: ctx, endpoint: listener.Addr().String(), services: c.services, path: c.path}, nil, defaultKeepAlive); err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+			if recorder.Code != c.code || strings.TrimSpace(recorder.Body.String()) != c.body {
+				t.Errorf("got %d %q, want %d %q", recorder.Code, recorder.Body.String(), c.code, c.body)
+			}
+		})
+	}
+
+	healthServer.Shutdown()
+	mux := runtime.NewServeMux()
+	if err := registerHealthz(mux, &healthz{ctx: context.Background(), endpoint: listener.Addr().String()}, nil, defaultKeepAlive); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, defaultHealthzPath, nil))
+	if body := strings.TrimSpace(recorder.Body.String()); recorder.Code != http.StatusServiceUnavailable || body != "NOT_SERVING" {
+		t.Errorf("whole server not serving: got %d %q", recorder.Code, body)
+	}
+}
+
+func TestHealthzClosed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mux := runtime.NewServeMux()
+	if err := registerHealthz(mux, &healthz{ctx: ctx, endpoint: "127.0.0.1:1"}, nil, defaultKeepAlive); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, defaultHealthzPath, nil))
+		if strings.Contains(recorder.Body.String(), "closing") {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("conn not closed, got %q", recorder.Body.String())
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestRegisterHealthz(t *testing.T) {
+	cases := []struct {
+		name    string
+		healthz *healthz
+	}{
+		{name: "no context", healthz: &healthz{endpoint: "127.0.0.1:1"}},
+		{name: "no endpoint", healthz: &healthz{ctx: context.Background()}},
+		{name: "relative path", healthz: &healthz{ctx: context.Background(), endpoint: "127.0.0.1:1", path: "healthz"}},
+	}
+
+	for _, c := range cases {
+		if err := registerHealthz(runtime.NewServeMux(), c.healthz, nil, defaultKeepAlive); err == nil {
+			t.Errorf("%s: should fail", c.name)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// MIMENewlineDelimitedJSON accept it, server-streaming responses framed as newline-delimited JSON
+	MIMENewlineDelimitedJSON = "application/x-ndjson"
+	// MIMEEventStream accept it, server-streaming responses framed as Server-Sent Events
+	MIMEEventStream = "text/event-stream"
+)
+
+func newJSONPb() *runtime.JSONPb {
+	return &runtime.JSONPb{
+		MarshalOptions: protojson.MarshalOptions{
+			UseProtoNames:   true,
+			EmitUnpopulated: true,
+		},
+		UnmarshalOptions: protojson.UnmarshalOptions{
+			DiscardUnknown: true,
+		},
+	}
+}
+
+var _ runtime.Marshaler = (*ndjsonMarshaler)(nil)
+var _ runtime.Marshaler = (*eventStreamMarshaler)(nil)
+
+// ndjsonMarshaler one json per line
+type ndjsonMarshaler struct {
+	*runtime.JSONPb
+}
+
+func (n *ndjsonMarshaler) ContentType(_ interface{}) string {
+	return MIMENewlineDelimitedJSON
+}
+
+// eventStreamMarshaler one json per event, errors are sent as 'error' event
+type eventStreamMarshaler struct {
+	*runtime.JSONPb
+}
+
+func (e *eventStreamMarshaler) ContentType(_ interface{}) string {
+	return MIMEEventStream
+}
+
+func (e *eventStreamMarshaler) Marshal(v interface{}) ([]byte, error) {
+	raw, err := e.JSONPb.Marshal(v)
+	if err != nil || !isStreamChunk(v) {
+		return raw, err
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(raw)+32))
+	if _, ok := v.(map[string]proto.Message); ok {
+		buf.WriteString("event: error\n")
+	}
+
+	buf.WriteString("data: ")
+	buf.Write(raw)
+	buf.WriteString("\n\n")
+
+	return buf.Bytes(), nil
+}
+
+// Delimiter every event has been terminated by Marshal, including the error one
+func (e *eventStreamMarshaler) Delimiter() []byte {
+	return nil
+}
+
+// isStreamChunk the result or error chunk of runtime.ForwardResponseStream, the others are unary responses or errors
+func isStreamChunk(v interface{}) bool {
+	switch chunk := v.(type) {
+	case map[string]interface{}:
+		_, ok := chunk["result"]
+		return ok
+	case map[string]proto.Message:
+		return chunk["error"] != nil
+	}
+	return false
+}
+
+// unframed the plain json marshaler of the framing one
+func unframed(marshaler runtime.Marshaler) (*runtime.JSONPb, bool) {
+	switch m := marshaler.(type) {
+	case *ndjsonMarshaler:
+		return m.JSONPb, true
+	case *eventStreamMarshaler:
+		return m.JSONPb, true
+	}
+	return nil, false
+}
+
+// unframedResponse the unary response accepted as MIMENewlineDelimitedJSON or MIMEEventStream sent as plain json;
+// runtime.ForwardResponseStream marks the response chunked before the content type set, which is kept.
+func unframedResponse(_ context.Context, w http.ResponseWriter, _ proto.Message) error {
+	header := w.Header()
+	if header.Get("Transfer-Encoding") == "chunked" {
+		return nil
+	}
+
+	if contentType := header.Get("Content-Type"); contentType == MIMENewlineDelimitedJSON || contentType == MIMEEventStream {
+		header.Set("Content-Type", "application/json")
+	}
+	return nil
+}
+
+// unframedHTTPError the error (of unary, or before streaming) sent as plain json, see runtime.DefaultHTTPErrorHandler
+func unframedHTTPError(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if plain, ok := unframed(marshaler); ok {
+		marshaler = plain
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+}
@@ -0,0 +1,100 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// compact protojson randomizes the spaces after , and :
+var compact = strings.NewReplacer(", ", ",", ": ", ":")
+
+func TestMarshalerFraming(t *testing.T) {
+	mux, _ := New()
+
+	// forward the unary response, the server-streaming responses (then an error), or the unary error
+	forwards := map[string]func(ctx context.Context, marshaler runtime.Marshaler, w http.ResponseWriter, req *http.Request){
+		"unary": func(ctx context.Context, marshaler runtime.Marshaler, w http.ResponseWriter, req *http.Request) {
+			runtime.ForwardResponseMessage(ctx, mux, marshaler, w, req, wrapperspb.String("a"), mux.GetForwardResponseOptions()...)
+		},
+		"stream": func(ctx context.Context, marshaler runtime.Marshaler, w http.ResponseWriter, req *http.Request) {
+			responses := []proto.Message{wrapperspb.String("a"), wrapperspb.String("b")}
+			recv := func() (proto.Message, error) {
+				if len(responses) == 0 {
+					return nil, io.EOF
+				}
+				resp := responses[0]
+				responses = responses[1:]
+				return resp, nil
+			}
+			runtime.ForwardResponseStream(ctx, mux, marshaler, w, req, recv, mux.GetForwardResponseOptions()...)
+		},
+		"stream error": func(ctx context.Context, marshaler runtime.Marshaler, w http.ResponseWriter, req *http.Request) {
+			sent := false
+			recv := func() (proto.Message, error) {
+				if sent {
+					return nil, status.Error(codes.Internal, "boom")
+				}
+				sent = true
+				return wrapperspb.String("a"), nil
+			}
+			runtime.ForwardResponseStream(ctx, mux, marshaler, w, req, recv, mux.GetForwardResponseOptions()...)
+		},
+		"unary error": func(ctx context.Context, marshaler runtime.Marshaler, w http.ResponseWriter, req *http.Request) {
+			runtime.HTTPError(ctx, mux, marshaler, w, req, status.Error(codes.NotFound, "none"))
+		},
+	}
+
+	cases := []struct {
+		name        string
+		accept      string
+		forward     string
+		contentType string
+		body        string
+	}{
+		{name: "unary default", forward: "unary", contentType: "application/json", body: `"a"`},
+		{name: "unary ndjson", accept: MIMENewlineDelimitedJSON, forward: "unary", contentType: "application/json", body: `"a"`},
+		{name: "unary event stream", accept: MIMEEventStream, forward: "unary", contentType: "application/json", body: `"a"`},
+		{name: "unary error event stream", accept: MIMEEventStream, forward: "unary error", contentType: "application/json", body: `{"code":5,"message":"none","details":[]}`},
+		{name: "stream default", forward: "stream", contentType: "application/json", body: "{\"result\":\"a\"}\n{\"result\":\"b\"}\n"},
+		{name: "stream ndjson", accept: MIMENewlineDelimitedJSON, forward: "stream", contentType: MIMENewlineDelimitedJSON, body: "{\"result\":\"a\"}\n{\"result\":\"b\"}\n"},
+		{name: "stream event stream", accept: MIMEEventStream, forward: "stream", contentType: MIMEEventStream, body: "data: {\"result\":\"a\"}\n\ndata: {\"result\":\"b\"}\n\n"},
+		{
+			name:        "stream error event stream",
+			accept:      MIMEEventStream,
+			forward:     "stream error",
+			contentType: MIMEEventStream,
+			body:        "data: {\"result\":\"a\"}\n\nevent: error\ndata: {\"error\":{\"code\":13,\"message\":\"boom\",\"details\":[]}}\n\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/x", nil)
+			if c.accept != "" {
+				req.Header.Set("Accept", c.accept)
+			}
+			_, marshaler := runtime.MarshalerForRequest(mux, req)
+
+			recorder := httptest.NewRecorder()
+			ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{})
+			forwards[c.forward](ctx, marshaler, recorder, req)
+
+			if contentType := recorder.Header().Get("Content-Type"); contentType != c.contentType {
+				t.Errorf("content type %q, want %q", contentType, c.contentType)
+			}
+			if body := compact.Replace(recorder.Body.String()); body != compact.Replace(c.body) {
+				t.Errorf("body %q, want %q", body, c.body)
+			}
+		})
+	}
+}
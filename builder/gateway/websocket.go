@@ -0,0 +1,192 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"golang.org/x/net/websocket"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// webSocketBridged mark the request bridged from websocket in context
+type webSocketBridged struct{}
+
+func bridgedFromWebSocket(ctx context.Context) bool {
+	bridged, _ := ctx.Value(webSocketBridged{}).(bool)
+	return bridged
+}
+
+func httpPattern(rule *annotations.HttpRule) (method, path string) {
+	switch x := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, x.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, x.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, x.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, x.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, x.Patch
+	case *annotations.HttpRule_Custom:
+		return strings.ToUpper(x.Custom.GetKind()), x.Custom.GetPath()
+	}
+
+	return "", ""
+}
+
+// registerWebSocket upgrade GET requests on the google.api.http path of client & bidi streaming methods,
+// the binding of them must be non-GET with body, which the frames streamed as
+func registerWebSocket(mux *runtime.ServeMux, descriptor protoreflect.FileDescriptor, origins []string) error {
+	services := descriptor.Services()
+	for i := 0; i < services.Len(); i++ {
+		methods := services.Get(i).Methods()
+
+		for k := 0; k < methods.Len(); k++ {
+			method := methods.Get(k)
+			if !method.IsStreamingClient() {
+				continue
+			}
+
+			rule := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if rule == nil {
+				continue
+			}
+
+			verb, path := httpPattern(rule)
+			if verb == "" || verb == http.MethodGet || rule.GetBody() == "" {
+				return fmt.Errorf("register websocket for %s err: the google.api.http binding must be non-GET with body", method.FullName())
+			}
+
+			if err := mux.HandlePath(http.MethodGet, path, webSocketBridge(mux, verb, origins)); err != nil {
+				return fmt.Errorf("register websocket for %s err: %v", method.FullName(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// allowedOrigin the same origin as the request, or one of the origins ("*" any), against cross-site websocket hijacking;
+// the request without Origin allowed, as it's not from browser
+func allowedOrigin(r *http.Request, origins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	for _, allowed := range origins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// webSocketBridge every text frame is a protojson request message, every response message sent as a text frame
+func webSocketBridge(mux *runtime.ServeMux, verb string, origins []string) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+			return
+		}
+
+		handshake := func(_ *websocket.Config, r *http.Request) error {
+			if !allowedOrigin(r, origins) {
+				return fmt.Errorf("origin %s not allowed", r.Header.Get("Origin"))
+			}
+			return nil
+		}
+
+		websocket.Server{Handshake: handshake, Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+
+			reader, writer := io.Pipe()
+			go func() {
+				defer writer.Close()
+
+				for {
+					var frame string
+					if err := websocket.Message.Receive(conn, &frame); err != nil {
+						return
+					}
+
+					if _, err := writer.Write(append([]byte(frame), '\n')); err != nil {
+						return
+					}
+				}
+			}()
+			defer reader.Close()
+
+			req := r.Clone(context.WithValue(r.Context(), webSocketBridged{}, true))
+			req.Method = verb
+			req.Body = reader
+			req.ContentLength = -1
+			for _, key := range []string{"Upgrade", "Connection", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions", "Sec-Websocket-Protocol"} {
+				req.Header.Del(key)
+			}
+			req.Header.Set("Accept", MIMENewlineDelimitedJSON)
+			if req.Header.Get("Grpc-Timeout") == "" {
+				req.Header.Set("Grpc-Timeout", "0S") // a socket lives longer than runtime.DefaultContextTimeout
+			}
+
+			writerToFrames := &webSocketResponseWriter{conn: conn, header: make(http.Header)}
+			mux.ServeHTTP(writerToFrames, req)
+			writerToFrames.flushRemaining()
+		}}.ServeHTTP(w, r)
+	}
+}
+
+var _ http.ResponseWriter = (*webSocketResponseWriter)(nil)
+var _ http.Flusher = (*webSocketResponseWriter)(nil)
+
+// webSocketResponseWriter split newline-delimited response into text frames
+type webSocketResponseWriter struct {
+	conn   *websocket.Conn
+	header http.Header
+	buf    bytes.Buffer
+}
+
+func (w *webSocketResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *webSocketResponseWriter) WriteHeader(statusCode int) {}
+
+func (w *webSocketResponseWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+
+	for {
+		index := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if index < 0 {
+			return len(p), nil
+		}
+
+		frame := w.buf.Next(index + 1)
+		if err := websocket.Message.Send(w.conn, string(frame[:index])); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (w *webSocketResponseWriter) Flush() {}
+
+// flushRemaining the error chunk written without delimiter
+func (w *webSocketResponseWriter) flushRemaining() {
+	if frame := bytes.TrimSpace(w.buf.Bytes()); len(frame) > 0 {
+		websocket.Message.Send(w.conn, string(frame))
+	}
+	w.buf.Reset()
+}
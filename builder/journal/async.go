@@ -0,0 +1,149 @@
+package journal
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/bluekaki/vv/internal/interceptor"
+
+	"github.com/pkg/errors"
+)
+
+// DropPolicy what to do when the buffer of async sink is full
+type DropPolicy int
+
+const (
+	// DropNewest discard the journal being written
+	DropNewest DropPolicy = iota
+	// DropOldest discard the oldest journal in buffer
+	DropOldest
+	// Block wait until the buffer has room
+	Block
+)
+
+// ErrDropped the journal discarded because of buffer full, the server reports it at most once per 10s
+var ErrDropped = interceptor.ErrJournalDropped
+
+var _ Sink = (*asyncSink)(nil)
+
+// AsyncOption how the async sink reports
+type AsyncOption func(*asyncOption)
+
+type asyncOption struct {
+	onError func(journalID string, err error)
+}
+
+// WithAsyncErrorHandler the error of writing journal to the wrapped sink in background, and the journal(s) discarded by DropOldest
+// (as ErrDropped at most once per 10s) reported to handler; the one discarded by DropNewest is returned by Write instead.
+func WithAsyncErrorHandler(handler func(journalID string, err error)) AsyncOption {
+	return func(opt *asyncOption) {
+		opt.onError = handler
+	}
+}
+
+// NewAsyncSink journal buffered by channel and written to sink in background
+func NewAsyncSink(sink Sink, size int, policy DropPolicy, options ...AsyncOption) Sink {
+	opt := new(asyncOption)
+	for _, f := range options {
+		f(opt)
+	}
+
+	if size <= 0 {
+		size = 1
+	}
+
+	async := &asyncSink{
+		sink:     sink,
+		policy:   policy,
+		onError:  opt.onError,
+		reporter: interceptor.NewDropReporter(opt.onError),
+		buffer:   make(chan *Journal, size),
+		stopped:  make(chan struct{}),
+	}
+
+	go async.loop()
+	return async
+}
+
+type asyncSink struct {
+	sink     Sink
+	policy   DropPolicy
+	onError  func(journalID string, err error)
+	reporter *interceptor.DropReporter
+
+	closeOnce sync.Once
+	mu        sync.RWMutex // guard buffer closed
+	closed    bool
+	buffer    chan *Journal
+	stopped   chan struct{}
+
+	dropped uint64
+}
+
+func (a *asyncSink) loop() {
+	defer close(a.stopped)
+
+	for journal := range a.buffer {
+		if err := a.sink.Write(journal); err != nil && a.onError != nil {
+			a.onError(journal.Id, err)
+		}
+	}
+}
+
+// Dropped count of journal(s) discarded
+func (a *asyncSink) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+func (a *asyncSink) Write(journal *Journal) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return errors.New("async sink closed")
+	}
+
+	switch a.policy {
+	case Block:
+		a.buffer <- journal
+		return nil
+
+	case DropOldest:
+		for {
+			select {
+			case a.buffer <- journal:
+				return nil
+			default:
+			}
+
+			select {
+			case oldest := <-a.buffer:
+				atomic.AddUint64(&a.dropped, 1)
+				a.reporter.Drop(oldest.Id)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case a.buffer <- journal:
+			return nil
+		default:
+			atomic.AddUint64(&a.dropped, 1)
+			return ErrDropped
+		}
+	}
+}
+
+// Close drain the buffer then close the sink
+func (a *asyncSink) Close() error {
+	a.closeOnce.Do(func() {
+		a.mu.Lock()
+		a.closed = true
+		close(a.buffer)
+		a.mu.Unlock()
+	})
+
+	<-a.stopped
+	return a.sink.Close()
+}
@@ -0,0 +1,152 @@
+package journal
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// gateSink the first Write blocked until release closed
+type gateSink struct {
+	sync.Mutex
+	started chan struct{}
+	release chan struct{}
+	err     error
+	ids     []string
+}
+
+func newGateSink(err error) *gateSink {
+	return &gateSink{started: make(chan struct{}), release: make(chan struct{}), err: err}
+}
+
+func (g *gateSink) Write(journal *Journal) error {
+	g.Lock()
+	first := len(g.ids) == 0
+	g.ids = append(g.ids, journal.Id)
+	g.Unlock()
+
+	if first {
+		close(g.started)
+		<-g.release
+	}
+
+	return g.err
+}
+
+func (g *gateSink) Close() error {
+	return nil
+}
+
+func TestAsyncSink(t *testing.T) {
+	cases := []struct {
+		name     string
+		policy   DropPolicy
+		sinkErr  error
+		writeErr []bool // of b, c
+		written  []string
+		dropped  uint64
+		reported []string
+	}{
+		{
+			name:     "drop newest",
+			policy:   DropNewest,
+			writeErr: []bool{false, true},
+			written:  []string{"a", "b"},
+			dropped:  1,
+		},
+		{
+			name:     "drop oldest",
+			policy:   DropOldest,
+			writeErr: []bool{false, false},
+			written:  []string{"a", "c"},
+			dropped:  1,
+			reported: []string{"b: " + ErrDropped.Error()},
+		},
+		{
+			name:     "block",
+			policy:   Block,
+			writeErr: []bool{false, false},
+			written:  []string{"a", "b", "c"},
+		},
+		{
+			name:     "sink error",
+			policy:   Block,
+			sinkErr:  errors.New("disk full"),
+			writeErr: []bool{false, false},
+			written:  []string{"a", "b", "c"},
+			reported: []string{"a: disk full", "b: disk full", "c: disk full"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				reported []string
+			)
+			handler := func(journalID string, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				reported = append(reported, journalID+": "+errors.Cause(err).Error())
+			}
+
+			sink := newGateSink(c.sinkErr)
+			async := NewAsyncSink(sink, 1, c.policy, WithAsyncErrorHandler(handler))
+
+			if err := async.Write(&Journal{Id: "a"}); err != nil {
+				t.Fatal(err)
+			}
+			<-sink.started // a taken by loop, b fills the buffer
+
+			if err := async.Write(&Journal{Id: "b"}); (err != nil) != c.writeErr[0] {
+				t.Fatalf("write b err %v", err)
+			}
+
+			written := make(chan error, 1)
+			go func() {
+				written <- async.Write(&Journal{Id: "c"})
+			}()
+
+			if c.policy != Block {
+				if err := <-written; (err != nil) != c.writeErr[1] {
+					t.Fatalf("write c err %v", err)
+				}
+			}
+
+			close(sink.release)
+			if c.policy == Block {
+				if err := <-written; err != nil {
+					t.Fatalf("write c err %v", err)
+				}
+			}
+
+			if err := async.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(sink.ids, ","); got != strings.Join(c.written, ",") {
+				t.Errorf("written %s, want %v", got, c.written)
+			}
+			if got := async.(*asyncSink).Dropped(); got != c.dropped {
+				t.Errorf("dropped %d, want %d", got, c.dropped)
+			}
+			if got := strings.Join(reported, "\n"); got != strings.Join(c.reported, "\n") {
+				t.Errorf("reported %q, want %q", reported, c.reported)
+			}
+		})
+	}
+}
+
+func TestAsyncSinkClosed(t *testing.T) {
+	async := NewAsyncSink(newGateSink(nil), 1, DropNewest)
+	close(async.(*asyncSink).sink.(*gateSink).release)
+
+	if err := async.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := async.Write(&Journal{Id: "a"}); err == nil {
+		t.Error("write after close should fail")
+	}
+}
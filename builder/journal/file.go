@@ -0,0 +1,129 @@
+package journal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/koketama/pbutil"
+	"github.com/pkg/errors"
+)
+
+var _ Sink = (*fileSink)(nil)
+
+// NewFileSink journal written as JSON lines, the file rotated when it's size exceeds maxBytes,
+// and only maxBackups rotated file(s) kept (0 keep all).
+func NewFileSink(filename string, maxBytes int64, maxBackups int) (Sink, error) {
+	if filename == "" {
+		return nil, errors.New("filename required")
+	}
+	if maxBytes <= 0 {
+		return nil, errors.New("maxBytes must be positive")
+	}
+
+	sink := &fileSink{
+		filename:   filename,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+
+	return sink, nil
+}
+
+type fileSink struct {
+	sync.Mutex
+	filename   string
+	maxBytes   int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+func (f *fileSink) open() error {
+	file, err := os.OpenFile(f.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "open %s err", f.filename)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Wrapf(err, "stat %s err", f.filename)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *fileSink) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return errors.Wrapf(err, "close %s err", f.filename)
+	}
+
+	backup := fmt.Sprintf("%s.%s", f.filename, time.Now().Format("20060102150405.000000000"))
+	if err := os.Rename(f.filename, backup); err != nil {
+		return errors.Wrapf(err, "rename %s err", f.filename)
+	}
+
+	if f.maxBackups > 0 {
+		backups, _ := filepath.Glob(f.filename + ".*")
+		sort.Strings(backups)
+
+		for len(backups) > f.maxBackups {
+			os.Remove(backups[0])
+			backups = backups[1:]
+		}
+	}
+
+	return f.open()
+}
+
+func (f *fileSink) Write(journal *Journal) error {
+	raw, err := pbutil.ProtoMessage2JSON(journal)
+	if err != nil {
+		return errors.Wrap(err, "marshal journal err")
+	}
+	line := append([]byte(raw), '\n')
+
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file == nil {
+		return errors.New("file sink closed")
+	}
+
+	if f.size > 0 && f.size+int64(len(line)) > f.maxBytes {
+		if err = f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	if err != nil {
+		return errors.Wrapf(err, "write %s err", f.filename)
+	}
+
+	return nil
+}
+
+func (f *fileSink) Close() error {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,102 @@
+package journal
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSink(t *testing.T) {
+	line := func(id string) int {
+		dir, err := ioutil.TempDir("", "journal")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		sink, err := NewFileSink(filepath.Join(dir, "journal.log"), 1<<20, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sink.Close()
+
+		if err = sink.Write(&Journal{Id: id}); err != nil {
+			t.Fatal(err)
+		}
+		return int(sink.(*fileSink).size)
+	}(strings.Repeat("x", 8))
+
+	cases := []struct {
+		name       string
+		maxBytes   int64
+		maxBackups int
+		writes     int
+		files      int // including the current one
+		lines      int // of the current one
+	}{
+		{name: "no rotation", maxBytes: int64(line * 3), writes: 3, files: 1, lines: 3},
+		{name: "rotated", maxBytes: int64(line * 2), writes: 5, files: 3, lines: 1},
+		{name: "backups kept", maxBytes: int64(line), maxBackups: 2, writes: 5, files: 3, lines: 1},
+		{name: "line larger than max", maxBytes: 1, writes: 2, files: 2, lines: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "journal")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			filename := filepath.Join(dir, "journal.log")
+			sink, err := NewFileSink(filename, c.maxBytes, c.maxBackups)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < c.writes; i++ {
+				if err = sink.Write(&Journal{Id: strings.Repeat("x", 8)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err = sink.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err = sink.Write(&Journal{}); err == nil {
+				t.Error("write after close should fail")
+			}
+
+			files, _ := filepath.Glob(filename + "*")
+			if len(files) != c.files {
+				t.Errorf("files %v, want %d", files, c.files)
+			}
+
+			raw, err := ioutil.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lines := strings.Count(string(raw), "\n"); lines != c.lines {
+				t.Errorf("lines %d, want %d", lines, c.lines)
+			}
+		})
+	}
+}
+
+func TestNewFileSink(t *testing.T) {
+	cases := []struct {
+		filename string
+		maxBytes int64
+	}{
+		{filename: "", maxBytes: 1},
+		{filename: "journal.log", maxBytes: 0},
+		{filename: filepath.Join("not", "exist", "journal.log"), maxBytes: 1},
+	}
+
+	for _, c := range cases {
+		if _, err := NewFileSink(c.filename, c.maxBytes, 0); err == nil {
+			t.Errorf("NewFileSink(%q, %d) should fail", c.filename, c.maxBytes)
+		}
+	}
+}
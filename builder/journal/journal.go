@@ -0,0 +1,43 @@
+package journal
+
+import (
+	"github.com/bluekaki/vv/internal/interceptor"
+	"github.com/bluekaki/vv/internal/protos/gen"
+
+	"go.uber.org/multierr"
+)
+
+// Journal the typed journal of a call
+type Journal = pb.Journal
+
+// Sink where the journal(s) written to
+type Sink = interceptor.JournalSink
+
+var _ Sink = (*multiSink)(nil)
+
+// NewMultiSink fan-out journal to every sink
+func NewMultiSink(sinks ...Sink) Sink {
+	return &multiSink{sinks: sinks}
+}
+
+type multiSink struct {
+	sinks []Sink
+}
+
+func (m *multiSink) Write(journal *Journal) error {
+	var err error
+	for _, sink := range m.sinks {
+		err = multierr.Append(err, sink.Write(journal))
+	}
+
+	return err
+}
+
+func (m *multiSink) Close() error {
+	var err error
+	for _, sink := range m.sinks {
+		err = multierr.Append(err, sink.Close())
+	}
+
+	return err
+}
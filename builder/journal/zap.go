@@ -0,0 +1,57 @@
+package journal
+
+import (
+	"github.com/koketama/pbutil"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var _ Sink = (*zapSink)(nil)
+
+// NewZapSink journal logged as a map field, info for success and error for failure;
+// the message tells who journaled it, e.g. "unary interceptor", "stream client", "gateway access".
+func NewZapSink(logger *zap.Logger) Sink {
+	return &zapSink{logger: logger}
+}
+
+type zapSink struct {
+	logger *zap.Logger
+}
+
+func (z *zapSink) Write(journal *Journal) error {
+	json, err := pbutil.ProtoMessage2Map(journal)
+	if err != nil {
+		return errors.Wrap(err, "marshal journal err")
+	}
+
+	msg := zapMessage(journal)
+	if journal.Success {
+		z.logger.Info(msg, zap.Any("journal", json))
+	} else {
+		z.logger.Error(msg, zap.Any("journal", json))
+	}
+
+	return nil
+}
+
+func (z *zapSink) Close() error {
+	z.logger.Sync()
+	return nil
+}
+
+// zapMessage who journaled, the gateway, the caller or the server, and the kind of rpc
+func zapMessage(journal *Journal) string {
+	if journal.Access != nil {
+		return "gateway access"
+	}
+
+	kind := "unary"
+	if journal.Stream != nil {
+		kind = "stream"
+	}
+
+	if journal.Client != nil {
+		return kind + " client"
+	}
+	return kind + " interceptor"
+}
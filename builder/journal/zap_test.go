@@ -0,0 +1,26 @@
+package journal
+
+import (
+	"testing"
+
+	"github.com/bluekaki/vv/internal/protos/gen"
+)
+
+func TestZapMessage(t *testing.T) {
+	cases := []struct {
+		journal *Journal
+		want    string
+	}{
+		{journal: &Journal{}, want: "unary interceptor"},
+		{journal: &Journal{Stream: new(pb.Stream)}, want: "stream interceptor"},
+		{journal: &Journal{Client: new(pb.Client)}, want: "unary client"},
+		{journal: &Journal{Client: new(pb.Client), Stream: new(pb.Stream)}, want: "stream client"},
+		{journal: &Journal{Access: new(pb.Access)}, want: "gateway access"},
+	}
+
+	for _, c := range cases {
+		if got := zapMessage(c.journal); got != c.want {
+			t.Errorf("zapMessage(%v) = %q, want %q", c.journal, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"time"
+
+	"github.com/bluekaki/vv/internal/interceptor"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// adminShutdownTimeout the in-flight admin requests waited at most when graceful stop
+const adminShutdownTimeout = time.Second * 5
+
+// WithAdmin the admin server listens on addr, serves /metrics (OpenMetrics format for exemplars), /healthz,
+// /readyz (serving and the whole server SERVING, see SetServing),
+// /debug/pprof/ and /debug/vv (the registered services and their options)
+func WithAdmin(addr string) Option {
+	return func(opt *option) {
+		opt.adminAddr = addr
+	}
+}
+
+// serveAdmin listen on addr, the admin server shut down along with the grpc server
+func (s *Server) serveAdmin(logger *zap.Logger, addr string, gatherer prometheus.Gatherer) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "listen admin err")
+	}
+
+	admin := &http.Server{Handler: s.adminMux(gatherer)}
+	go func() {
+		if err := admin.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Error("admin server err", zap.Error(err))
+		}
+	}()
+
+	s.onShutdown(func(graceful bool) {
+		if !graceful {
+			admin.Close()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), adminShutdownTimeout)
+		defer cancel()
+
+		if err := admin.Shutdown(ctx); err != nil {
+			logger.Error("shutdown admin server err", zap.Error(err))
+		}
+	})
+
+	return nil
+}
+
+func (s *Server) adminMux(gatherer prometheus.Gatherer) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !s.ready() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.HandleFunc("/debug/vv", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(s.introspect())
+	})
+
+	return mux
+}
+
+type introspectedService struct {
+	Name    string               `json:"name"`
+	Methods []introspectedMethod `json:"methods"`
+}
+
+type introspectedMethod struct {
+	Name          string          `json:"name"`
+	ClientStreams bool            `json:"client_streams"`
+	ServerStreams bool            `json:"server_streams"`
+	Options       json.RawMessage `json:"options,omitempty"` // the vv options included
+}
+
+// introspect the registered services, methods and their options
+func (s *Server) introspect() []introspectedService {
+	infos := s.GetServiceInfo()
+
+	services := make([]introspectedService, 0, len(infos))
+	for name, info := range infos {
+		service := introspectedService{Name: name}
+
+		for _, method := range info.Methods {
+			introspected := introspectedMethod{
+				Name:          method.Name,
+				ClientStreams: method.IsClientStream,
+				ServerStreams: method.IsServerStream,
+			}
+
+			if options := interceptor.FileDescriptor.Options("/" + name + "/" + method.Name); options != nil {
+				introspected.Options, _ = protojson.Marshal(options)
+			}
+
+			service.Methods = append(service.Methods, introspected)
+		}
+
+		services = append(services, service)
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services
+}
@@ -6,7 +6,8 @@ import (
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
-// ParseFileDescriptorP parse file descriptor
+// ParseFileDescriptorP parse file descriptor, pass it as the descriptor handler of the generated RegisterXServer;
+// the service(s) of it marked SERVING by the Server they registered on once serving.
 func ParseFileDescriptorP(descriptor protoreflect.FileDescriptor) {
 	if descriptor == nil {
 		panic("file descriptor required")
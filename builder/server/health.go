@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bluekaki/vv/internal/interceptor"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// markServing mark the service(s) registered on s by the generated RegisterXServer with ParseFileDescriptorP SERVING,
+// the flipped one(s) left as is
+func (s *Server) markServing() {
+	for service, info := range s.GetServiceInfo() {
+		if len(info.Methods) == 0 || interceptor.FileDescriptor.Options(fmt.Sprintf("/%s/%s", service, info.Methods[0].Name)) == nil {
+			continue // not parsed, e.g. grpc.health.v1.Health itself
+		}
+
+		if _, err := s.health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service}); status.Code(err) == codes.NotFound {
+			s.health.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+		}
+	}
+}
+
+// SetServing flip the status of service reported by grpc.health.v1.Health, e.g. NOT_SERVING while it's dependency outage;
+// the empty service means the whole server. All of them turn NOT_SERVING once stopping, and can't be flipped back.
+func (s *Server) SetServing(service string, serving bool) {
+	servingStatus := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		servingStatus = healthpb.HealthCheckResponse_SERVING
+	}
+
+	s.health.SetServingStatus(service, servingStatus)
+}
+
+// registerHealth register the grpc.health.v1.Health service, the whole server is SERVING by default
+func (s *Server) registerHealth() {
+	s.health = health.NewServer()
+	healthpb.RegisterHealthServer(s.Server, s.health)
+}
+
+// healthy the whole server is SERVING
+func (s *Server) healthy() bool {
+	resp, err := s.health.Check(context.Background(), new(healthpb.HealthCheckRequest))
+	return err == nil && resp.Status == healthpb.HealthCheckResponse_SERVING
+}
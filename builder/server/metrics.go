@@ -1,45 +1,35 @@
 package server
 
 import (
-	"net/http"
-	"time"
-
 	"github.com/bluekaki/vv/internal/interceptor"
 
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/prometheus/client_golang/prometheus/push"
-	"go.uber.org/zap"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// Metrics all metrics of a server
+type Metrics = interceptor.Metrics
+
 // WithPrometheus prometheus metrics exposes on http://addr/metrics
+//
+// Deprecated: use WithAdmin, which serves the metrics along with health & pprof
 func WithPrometheus(addr string) Option {
+	return WithAdmin(addr)
+}
+
+// WithPrometheusRegistry metrics registered to registerer (even without WithAdmin or WithPrometheusPush), and WithAdmin
+// exposes the gatherer, default prometheus.DefaultRegisterer & prometheus.DefaultGatherer; gatherer could be nil if registerer
+// is a *prometheus.Registry. The metrics are created only if any of them is setup.
+func WithPrometheusRegistry(registerer prometheus.Registerer, gatherer prometheus.Gatherer) Option {
 	return func(opt *option) {
-		opt.prometheusHandler = func(logger *zap.Logger) {
-			http.Handle("/metrics", promhttp.Handler())
-			go func() {
-				if err := http.ListenAndServe(addr, nil); err != nil {
-					panic(err)
-				}
-			}()
-		}
+		opt.registerer = registerer
+		opt.gatherer = gatherer
 	}
 }
 
-// WithPrometheusPush  push prometheus metrics to the Pushgateway
-func WithPrometheusPush(gateway string) Option {
+// WithMetricsNamespace the namespace & subsystem of metrics, default bluekaki & vv
+func WithMetricsNamespace(namespace, subsystem string) Option {
 	return func(opt *option) {
-		opt.prometheusHandler = func(logger *zap.Logger) {
-			go func() {
-				pusher := push.New(gateway, "bluekaiki_vv_metrics").
-					Collector(interceptor.MetricsRequestCost).
-					Collector(interceptor.MetricsError)
-
-				for range time.NewTicker(time.Second * 5).C {
-					if err := pusher.Add(); err != nil {
-						logger.Error("post metrics to prometheus pushgateway err", zap.Error(err))
-					}
-				}
-			}()
-		}
+		opt.metricsNamespace = namespace
+		opt.metricsSubsystem = subsystem
 	}
 }
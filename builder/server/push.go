@@ -0,0 +1,150 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPushJob      = "bluekaiki_vv_metrics"
+	defaultPushInterval = time.Second * 5
+	pushTimeout         = time.Second * 5
+)
+
+// PushOption how setup the pushgateway
+type PushOption func(*pushOption)
+
+type pushOption struct {
+	job              string
+	grouping         [][2]string // name, value
+	interval         time.Duration
+	collectors       []prometheus.Collector
+	username         string
+	password         string
+	deleteOnShutdown bool
+}
+
+// WithPushJob the job name, default bluekaiki_vv_metrics
+func WithPushJob(job string) PushOption {
+	return func(opt *pushOption) {
+		opt.job = job
+	}
+}
+
+// WithPushGrouping add a grouping key label, e.g. instance, pod or version
+func WithPushGrouping(name, value string) PushOption {
+	return func(opt *pushOption) {
+		opt.grouping = append(opt.grouping, [2]string{name, value})
+	}
+}
+
+// WithPushInterval default 5s
+func WithPushInterval(interval time.Duration) PushOption {
+	return func(opt *pushOption) {
+		opt.interval = interval
+	}
+}
+
+// WithPushCollectors push the application's collector(s) along with the vv ones
+func WithPushCollectors(collectors ...prometheus.Collector) PushOption {
+	return func(opt *pushOption) {
+		opt.collectors = append(opt.collectors, collectors...)
+	}
+}
+
+// WithPushBasicAuth the basic auth of pushgateway
+func WithPushBasicAuth(username, password string) PushOption {
+	return func(opt *pushOption) {
+		opt.username = username
+		opt.password = password
+	}
+}
+
+// WithPushDeleteOnShutdown delete the metrics of the grouping key from pushgateway after the final push on shutdown,
+// so that the metrics of a long-running service not outlive it
+func WithPushDeleteOnShutdown() PushOption {
+	return func(opt *pushOption) {
+		opt.deleteOnShutdown = true
+	}
+}
+
+// WithPrometheusPush push prometheus metrics to the Pushgateway periodically,
+// and push the last ones when the server stopped (then deleted if WithPushDeleteOnShutdown)
+func WithPrometheusPush(gateway string, options ...PushOption) Option {
+	return func(opt *option) {
+		opt.pushHandler = func(server *Server, logger *zap.Logger, metrics *Metrics) error {
+			if gateway == "" {
+				return errors.New("pushgateway required")
+			}
+
+			pushOpt := &pushOption{
+				job:      defaultPushJob,
+				interval: defaultPushInterval,
+			}
+			for _, f := range options {
+				f(pushOpt)
+			}
+
+			if pushOpt.job == "" {
+				return errors.New("pushgateway job required")
+			}
+			if pushOpt.interval <= 0 {
+				return errors.Errorf("pushgateway interval %s must be positive", pushOpt.interval)
+			}
+
+			pusher := push.New(gateway, pushOpt.job).Client(&http.Client{Timeout: pushTimeout})
+			for _, grouping := range pushOpt.grouping {
+				pusher = pusher.Grouping(grouping[0], grouping[1])
+			}
+			if pushOpt.username != "" {
+				pusher = pusher.BasicAuth(pushOpt.username, pushOpt.password)
+			}
+			for _, collector := range append(metrics.Collectors(), pushOpt.collectors...) {
+				pusher = pusher.Collector(collector)
+			}
+
+			stop := make(chan struct{})
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+
+				ticker := time.NewTicker(pushOpt.interval)
+				defer ticker.Stop()
+
+				for {
+					select {
+					case <-stop:
+						return
+
+					case <-ticker.C:
+						if err := pusher.Add(); err != nil {
+							logger.Error("post metrics to prometheus pushgateway err", zap.Error(err))
+						}
+					}
+				}
+			}()
+
+			server.onShutdown(func(bool) {
+				close(stop)
+				<-stopped
+
+				if err := pusher.Add(); err != nil {
+					logger.Error("post the last metrics to prometheus pushgateway err", zap.Error(err))
+				}
+
+				if pushOpt.deleteOnShutdown {
+					if err := pusher.Delete(); err != nil {
+						logger.Error("delete metrics from prometheus pushgateway err", zap.Error(err))
+					}
+				}
+			})
+
+			return nil
+		}
+	}
+}
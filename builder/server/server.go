@@ -1,15 +1,23 @@
 package server
 
 import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/bluekaki/vv/builder/journal"
+	"github.com/bluekaki/vv/builder/tracing"
 	"github.com/bluekaki/vv/internal/interceptor"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/keepalive"
 )
 
@@ -35,7 +43,23 @@ type option struct {
 	credential        credentials.TransportCredentials
 	enforcementPolicy *keepalive.EnforcementPolicy
 	keepalive         *keepalive.ServerParameters
-	prometheusHandler func(*zap.Logger)
+	adminAddr         string
+	pushHandler       func(server *Server, logger *zap.Logger, metrics *Metrics) error
+	registerer        prometheus.Registerer
+	gatherer          prometheus.Gatherer
+	metricsNamespace  string
+	metricsSubsystem  string
+	journalSink       journal.Sink
+	loggedMetadata    []string // nil the default
+	extraMetadata     []string
+	redactedMetadata  []string
+	maxPayloadBytes   int
+	maxMetadataBytes  int
+	maxStreamMessages int
+	hashKey           []byte
+	requireStreamSign bool
+	trustJournalID    func(ctx context.Context) bool
+	traceExporter     tracing.Exporter
 }
 
 // WithCredential setup credential for tls
@@ -59,8 +83,160 @@ func WithKeepAlive(keepalive *keepalive.ServerParameters) Option {
 	}
 }
 
-// New create a grpc server
-func New(logger *zap.Logger, options ...Option) (*grpc.Server, error) {
+// WithJournalSink setup where the journal(s) written to, default logged by zap; it's closed when the server stopped
+func WithJournalSink(sink journal.Sink) Option {
+	return func(opt *option) {
+		opt.journalSink = sink
+	}
+}
+
+// WithJournalMetadata journal the metadata key(s) as well as the default (or replaced) one(s), e.g. x-tenant-id, user-agent
+func WithJournalMetadata(keys ...string) Option {
+	return func(opt *option) {
+		opt.extraMetadata = append(opt.extraMetadata, keys...)
+	}
+}
+
+// WithJournalMetadataOnly replace the default metadata key(s) journaled, default authorization, proxy-authorization,
+// date, method, uri, body, x-forwarded-for and x-forwarded-host
+func WithJournalMetadataOnly(keys ...string) Option {
+	return func(opt *option) {
+		opt.loggedMetadata = append([]string{}, keys...)
+	}
+}
+
+// WithRedactedMetadata metadata value(s) replaced in journal, default authorization and proxy-authorization
+func WithRedactedMetadata(keys ...string) Option {
+	return func(opt *option) {
+		opt.redactedMetadata = append([]string{}, keys...)
+	}
+}
+
+// WithJournalMaxPayloadBytes payload larger than it journaled as a truncation marker with it's size and sha256
+func WithJournalMaxPayloadBytes(n int) Option {
+	return func(opt *option) {
+		opt.maxPayloadBytes = n
+	}
+}
+
+// WithJournalMaxMetadataBytes metadata value larger than it journaled as a truncation marker with it's size and sha256
+func WithJournalMaxMetadataBytes(n int) Option {
+	return func(opt *option) {
+		opt.maxMetadataBytes = n
+	}
+}
+
+// WithJournalMaxStreamMessages the first n messages of stream journaled, the rest counted as dropped, default 100
+func WithJournalMaxStreamMessages(n int) Option {
+	return func(opt *option) {
+		opt.maxStreamMessages = n
+	}
+}
+
+// WithJournalHashKey the key of HMAC-SHA256 hashing the sensitive fields (options.sensitive HASH) in journal,
+// share it among the servers to correlate the hashes, default a random one per server
+func WithJournalHashKey(key []byte) Option {
+	return func(opt *option) {
+		opt.hashKey = append([]byte{}, key...)
+	}
+}
+
+// WithUpstreamJournalID honour the journal_id from upstream (or X-Journal-Id via gateway) when trusted returns true,
+// nil trusts every upstream. The malformed one is always replaced by a random one.
+func WithUpstreamJournalID(trusted func(ctx context.Context) bool) Option {
+	return func(opt *option) {
+		if trusted == nil {
+			trusted = func(context.Context) bool { return true }
+		}
+		opt.trustJournalID = trusted
+	}
+}
+
+// WithStreamMessageSignRequired the stream of method with options.proxy_authorization rejected as Unauthenticated,
+// unless every message of it signed by the client (see WithStreamMessageSign of builder/client)
+func WithStreamMessageSignRequired() Option {
+	return func(opt *option) {
+		opt.requireStreamSign = true
+	}
+}
+
+// WithTracing a span created for every rpc, child of the traceparent from upstream, and exported by exporter;
+// the exporter may be shared (e.g. with the gateway or clients), so close it after all of them stopped
+func WithTracing(exporter tracing.Exporter) Option {
+	return func(opt *option) {
+		opt.traceExporter = exporter
+	}
+}
+
+// Server the grpc server with grpc.health.v1.Health registered, the admin server & pusher shut down along with it.
+// Serve & stop it by Serve, GracefulStop and Stop of it, the ones of the embedded *grpc.Server skip the hooks.
+type Server struct {
+	*grpc.Server
+	health   *health.Server
+	serving  int32 // count of listeners serving
+	draining int32 // 1 once stopping
+
+	sync.Mutex
+	shutdownHooks []func(graceful bool)
+	shutdownOnce  sync.Once
+}
+
+// Serve ready (see /readyz of admin) while serving, the services registered with ParseFileDescriptorP marked SERVING
+func (s *Server) Serve(listener net.Listener) error {
+	s.markServing()
+
+	atomic.AddInt32(&s.serving, 1)
+	defer atomic.AddInt32(&s.serving, -1)
+
+	return s.Server.Serve(listener)
+}
+
+// GracefulStop turn all services NOT_SERVING and stop the grpc server gracefully, then shut down the admin server gracefully
+func (s *Server) GracefulStop() {
+	atomic.StoreInt32(&s.draining, 1)
+	s.health.Shutdown()
+	s.Server.GracefulStop()
+	s.shutdown(true)
+}
+
+// Stop turn all services NOT_SERVING and stop the grpc server and the admin server immediately
+func (s *Server) Stop() {
+	atomic.StoreInt32(&s.draining, 1)
+	s.health.Shutdown()
+	s.Server.Stop()
+	s.shutdown(false)
+}
+
+func (s *Server) ready() bool {
+	return atomic.LoadInt32(&s.serving) > 0 && atomic.LoadInt32(&s.draining) == 0 && s.healthy()
+}
+
+// onShutdown the hooks called once, in order, after the grpc server stopped
+func (s *Server) onShutdown(hook func(graceful bool)) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.shutdownHooks = append(s.shutdownHooks, hook)
+}
+
+func (s *Server) shutdown(graceful bool) {
+	s.shutdownOnce.Do(func() {
+		s.Lock()
+		hooks := s.shutdownHooks
+		s.Unlock()
+
+		for _, hook := range hooks {
+			hook(graceful)
+		}
+	})
+}
+
+// New create a grpc server, the services registered by the generated RegisterXServer with ParseFileDescriptorP
+// are marked SERVING in grpc.health.v1.Health once serving.
+//
+// New returns *Server instead of *grpc.Server since the admin server, see "Breaking changes" of README:
+// serve & stop it by *Server, never by the embedded *grpc.Server.
+func New(logger *zap.Logger, options ...Option) (*Server, error) {
 	if logger == nil {
 		return nil, errors.New("logger required")
 	}
@@ -70,8 +246,32 @@ func New(logger *zap.Logger, options ...Option) (*grpc.Server, error) {
 		f(opt)
 	}
 
-	if opt.prometheusHandler != nil {
-		opt.prometheusHandler(logger)
+	var (
+		metrics  *Metrics
+		gatherer prometheus.Gatherer
+	)
+	if opt.adminAddr != "" || opt.pushHandler != nil || opt.registerer != nil {
+		var registerer prometheus.Registerer
+		registerer, gatherer = prometheus.DefaultRegisterer, prometheus.DefaultGatherer
+		if opt.registerer != nil {
+			registerer, gatherer = opt.registerer, opt.gatherer
+			if gatherer == nil {
+				gatherer, _ = registerer.(prometheus.Gatherer) // e.g. *prometheus.Registry
+			}
+			if gatherer == nil && opt.adminAddr != "" {
+				return nil, errors.New("prometheus gatherer required")
+			}
+		}
+
+		namespace, subsystem := interceptor.DefaultMetricsNamespace, interceptor.DefaultMetricsSubsystem
+		if opt.metricsNamespace != "" || opt.metricsSubsystem != "" {
+			namespace, subsystem = opt.metricsNamespace, opt.metricsSubsystem
+		}
+
+		var err error
+		if metrics, err = interceptor.NewMetrics(registerer, namespace, subsystem); err != nil {
+			return nil, err
+		}
 	}
 
 	enforcementPolicy := defaultEnforcementPolicy
@@ -84,7 +284,35 @@ func New(logger *zap.Logger, options ...Option) (*grpc.Server, error) {
 		keepalive = opt.keepalive
 	}
 
-	serverInterceptor := interceptor.NewServerInterceptor(logger, opt.prometheusHandler != nil)
+	journalSink := opt.journalSink
+	if journalSink == nil {
+		journalSink = journal.NewZapSink(logger)
+	}
+
+	loggedMetadata := opt.loggedMetadata
+	if loggedMetadata == nil {
+		loggedMetadata = interceptor.DefaultLoggedMetadata()
+	}
+	loggedMetadata = append(loggedMetadata, opt.extraMetadata...)
+
+	redactedMetadata := opt.redactedMetadata
+	if redactedMetadata == nil {
+		redactedMetadata = []string{interceptor.Authorization, interceptor.ProxyAuthorization}
+	}
+
+	serverInterceptor := interceptor.NewServerInterceptor(logger, &interceptor.JournalConfig{
+		Sink:              journalSink,
+		LoggedMetadata:    loggedMetadata,
+		RedactedMetadata:  redactedMetadata,
+		MaxPayloadBytes:   opt.maxPayloadBytes,
+		MaxMetadataBytes:  opt.maxMetadataBytes,
+		MaxStreamMessages: opt.maxStreamMessages,
+		HashKey:           opt.hashKey,
+		TrustUpstreamID:   opt.trustJournalID,
+		OnError: func(journalID string, err error) {
+			logger.Error("write journal err", zap.String("journal_id", journalID), zap.Error(err))
+		},
+	}, opt.traceExporter, metrics, opt.requireStreamSign)
 
 	serverOptions := []grpc.ServerOption{
 		grpc.KeepaliveEnforcementPolicy(*enforcementPolicy),
@@ -97,5 +325,35 @@ func New(logger *zap.Logger, options ...Option) (*grpc.Server, error) {
 		serverOptions = append(serverOptions, grpc.Creds(opt.credential))
 	}
 
-	return grpc.NewServer(serverOptions...), nil
+	server := &Server{Server: grpc.NewServer(serverOptions...)}
+	server.registerHealth()
+
+	if metrics != nil { // the objectives shared by servers of same registry, each reports it's own methods
+		metrics.SLOObjectives.Add(server.Server)
+		server.onShutdown(func(bool) {
+			metrics.SLOObjectives.Remove(server.Server)
+		})
+	}
+
+	server.onShutdown(func(bool) { // flush the buffered journal(s)
+		if err := journalSink.Close(); err != nil {
+			logger.Error("close journal sink err", zap.Error(err))
+		}
+	})
+
+	if opt.adminAddr != "" {
+		if err := server.serveAdmin(logger, opt.adminAddr, gatherer); err != nil {
+			server.Stop() // release the health registration
+			return nil, err
+		}
+	}
+
+	if opt.pushHandler != nil {
+		if err := opt.pushHandler(server, logger, metrics); err != nil {
+			server.Stop() // release the admin server
+			return nil, err
+		}
+	}
+
+	return server, nil
 }
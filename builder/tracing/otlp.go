@@ -0,0 +1,333 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultOTLPEndpoint  = "http://127.0.0.1:4318/v1/traces"
+	defaultBatchSize     = 512
+	defaultQueueSize     = 2048
+	defaultFlushInterval = 5 * time.Second
+	defaultOTLPTimeout   = 10 * time.Second
+)
+
+// ErrQueueFull the span dropped because of exporter's queue full
+var ErrQueueFull = errors.New("span dropped, otlp exporter queue full")
+
+// OTLPOption how setup otlp exporter
+type OTLPOption func(*otlpOption)
+
+type otlpOption struct {
+	endpoint      string
+	headers       map[string]string
+	client        *http.Client
+	batchSize     int
+	queueSize     int
+	flushInterval time.Duration
+	onError       func(error)
+}
+
+// WithEndpoint the full url of collector, default http://127.0.0.1:4318/v1/traces
+func WithEndpoint(endpoint string) OTLPOption {
+	return func(opt *otlpOption) {
+		opt.endpoint = endpoint
+	}
+}
+
+// WithHeader add a http header to every export request
+func WithHeader(key, value string) OTLPOption {
+	return func(opt *otlpOption) {
+		opt.headers[key] = value
+	}
+}
+
+// WithHTTPClient setup http client, default timeout 10s
+func WithHTTPClient(client *http.Client) OTLPOption {
+	return func(opt *otlpOption) {
+		opt.client = client
+	}
+}
+
+// WithBatch setup the max span(s) in a request and the max interval between requests
+func WithBatch(size int, interval time.Duration) OTLPOption {
+	return func(opt *otlpOption) {
+		opt.batchSize = size
+		opt.flushInterval = interval
+	}
+}
+
+// WithQueueSize the span(s) waiting for export, the newest dropped if full
+func WithQueueSize(size int) OTLPOption {
+	return func(opt *otlpOption) {
+		opt.queueSize = size
+	}
+}
+
+// WithErrorHandler handle the error of background export
+func WithErrorHandler(handler func(error)) OTLPOption {
+	return func(opt *otlpOption) {
+		opt.onError = handler
+	}
+}
+
+var _ Exporter = (*otlpExporter)(nil)
+
+// NewOTLPExporter export span(s) to OpenTelemetry collector by OTLP/HTTP with JSON encoding,
+// close it (the queued span(s) flushed) after the server, gateway and client(s) using it stopped
+func NewOTLPExporter(serviceName string, options ...OTLPOption) (Exporter, error) {
+	if serviceName == "" {
+		return nil, errors.New("serviceName required")
+	}
+
+	opt := &otlpOption{
+		endpoint:      defaultOTLPEndpoint,
+		headers:       make(map[string]string),
+		client:        &http.Client{Timeout: defaultOTLPTimeout},
+		batchSize:     defaultBatchSize,
+		queueSize:     defaultQueueSize,
+		flushInterval: defaultFlushInterval,
+	}
+	for _, f := range options {
+		f(opt)
+	}
+
+	if opt.batchSize <= 0 || opt.queueSize <= 0 || opt.flushInterval <= 0 {
+		return nil, errors.New("batch size, queue size and flush interval must be positive")
+	}
+
+	exporter := &otlpExporter{
+		serviceName: serviceName,
+		option:      opt,
+		queue:       make(chan *Span, opt.queueSize),
+		stop:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+
+	go exporter.loop()
+	return exporter, nil
+}
+
+type otlpExporter struct {
+	serviceName string
+	option      *otlpOption
+
+	closeOnce sync.Once
+	queue     chan *Span
+	stop      chan struct{}
+	stopped   chan struct{}
+}
+
+func (o *otlpExporter) Export(span *Span) error {
+	select {
+	case <-o.stop:
+		return errors.New("otlp exporter closed")
+	default:
+	}
+
+	select {
+	case o.queue <- span:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close flush the queued span(s)
+func (o *otlpExporter) Close() error {
+	o.closeOnce.Do(func() {
+		close(o.stop)
+	})
+
+	<-o.stopped
+	return nil
+}
+
+func (o *otlpExporter) loop() {
+	defer close(o.stopped)
+
+	ticker := time.NewTicker(o.option.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, o.option.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := o.send(batch); err != nil && o.option.onError != nil {
+			o.option.onError(err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-o.queue:
+			if batch = append(batch, span); len(batch) >= o.option.batchSize {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+
+		case <-o.stop:
+			for {
+				select {
+				case span := <-o.queue:
+					if batch = append(batch, span); len(batch) >= o.option.batchSize {
+						flush()
+					}
+
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (o *otlpExporter) send(spans []*Span) error {
+	raw, err := json.Marshal(o.encode(spans))
+	if err != nil {
+		return errors.Wrap(err, "marshal otlp request err")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, o.option.endpoint, bytes.NewReader(raw))
+	if err != nil {
+		return errors.Wrap(err, "create otlp request err")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range o.option.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := o.option.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "export %d span(s) err", len(spans))
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("export %d span(s) err, status: %s, body: %s", len(spans), resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// the json mapping of opentelemetry/proto/collector/trace/v1.ExportTraceServiceRequest
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	TraceState        string         `json:"traceState,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func otlpAttribute(key string, value interface{}) otlpKeyValue {
+	switch v := value.(type) {
+	case string:
+		return otlpKeyValue{Key: key, Value: map[string]interface{}{"stringValue": v}}
+	case bool:
+		return otlpKeyValue{Key: key, Value: map[string]interface{}{"boolValue": v}}
+	case int64:
+		return otlpKeyValue{Key: key, Value: map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}}
+	case float64:
+		return otlpKeyValue{Key: key, Value: map[string]interface{}{"doubleValue": v}}
+	}
+
+	return otlpKeyValue{Key: key, Value: map[string]interface{}{"stringValue": ""}}
+}
+
+func (o *otlpExporter) encode(spans []*Span) *otlpRequest {
+	scope := otlpScopeSpans{
+		Scope: otlpScope{Name: "github.com/bluekaki/vv"},
+		Spans: make([]otlpSpan, len(spans)),
+	}
+
+	for i, span := range spans {
+		encoded := otlpSpan{
+			TraceID:           span.SpanContext.TraceID.String(),
+			SpanID:            span.SpanContext.SpanID.String(),
+			TraceState:        span.SpanContext.TraceState,
+			Name:              span.Name,
+			Kind:              int(span.Kind),
+			StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+			Status: otlpStatus{
+				Code:    int(span.StatusCode),
+				Message: span.StatusMessage,
+			},
+		}
+
+		if span.ParentSpanID.IsValid() {
+			encoded.ParentSpanID = span.ParentSpanID.String()
+		}
+
+		for key, value := range span.Attributes {
+			encoded.Attributes = append(encoded.Attributes, otlpAttribute(key, value))
+		}
+
+		scope.Spans[i] = encoded
+	}
+
+	return &otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpKeyValue{otlpAttribute("service.name", o.serviceName)},
+			},
+			ScopeSpans: []otlpScopeSpans{scope},
+		}},
+	}
+}
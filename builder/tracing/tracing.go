@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"sync"
+
+	"github.com/bluekaki/vv/internal/tracing"
+)
+
+// Span a finished span
+type Span = tracing.Span
+
+// Exporter where the finished span(s) exported to
+type Exporter = tracing.Exporter
+
+// SpanContext the propagated part of span
+type SpanContext = tracing.SpanContext
+
+const (
+	// Traceparent W3C Trace Context header
+	Traceparent = tracing.Traceparent
+	// Tracestate W3C Trace Context header
+	Tracestate = tracing.Tracestate
+
+	// SpanKindServer a span covering server-side handling of RPC
+	SpanKindServer = tracing.SpanKindServer
+	// SpanKindClient a span describing a request to remote service
+	SpanKindClient = tracing.SpanKindClient
+
+	// StatusUnset the default status
+	StatusUnset = tracing.StatusUnset
+	// StatusError contains an error
+	StatusError = tracing.StatusError
+)
+
+var _ Exporter = (*InMemoryExporter)(nil)
+
+// NewInMemoryExporter keep the exported span(s) in memory, for tests
+func NewInMemoryExporter() *InMemoryExporter {
+	return new(InMemoryExporter)
+}
+
+// InMemoryExporter keep the exported span(s) in memory
+type InMemoryExporter struct {
+	sync.Mutex
+	spans []*Span
+}
+
+// Export append the span
+func (i *InMemoryExporter) Export(span *Span) error {
+	i.Lock()
+	defer i.Unlock()
+
+	i.spans = append(i.spans, span)
+	return nil
+}
+
+// Close nothing to do
+func (i *InMemoryExporter) Close() error {
+	return nil
+}
+
+// Spans the exported span(s) in order
+func (i *InMemoryExporter) Spans() []*Span {
+	i.Lock()
+	defer i.Unlock()
+
+	return append([]*Span(nil), i.spans...)
+}
+
+// Reset drop the exported span(s)
+func (i *InMemoryExporter) Reset() {
+	i.Lock()
+	defer i.Unlock()
+
+	i.spans = nil
+}
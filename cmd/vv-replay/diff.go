@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// diffMessage the differences between recorded and replayed message, as '- path: value' and '+ path: value' lines
+func diffMessage(resolver *registry, recorded, replayed proto.Message) ([]string, error) {
+	if proto.Equal(recorded, replayed) {
+		return nil, nil
+	}
+
+	marshal := protojson.MarshalOptions{Resolver: resolver, UseProtoNames: true, EmitUnpopulated: true}
+
+	var left, right interface{}
+	for _, item := range []struct {
+		message proto.Message
+		value   *interface{}
+	}{
+		{recorded, &left},
+		{replayed, &right},
+	} {
+		raw, err := marshal.Marshal(item.message)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal message err")
+		}
+
+		if err = json.Unmarshal(raw, item.value); err != nil {
+			return nil, errors.Wrap(err, "unmarshal message err")
+		}
+	}
+
+	var lines []string
+	diffValue("payload", left, right, &lines)
+	return lines, nil
+}
+
+func diffValue(path string, left, right interface{}, lines *[]string) {
+	if reflect.DeepEqual(left, right) {
+		return
+	}
+
+	switch l := left.(type) {
+	case map[string]interface{}:
+		if r, ok := right.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(l)+len(r))
+			for key := range l {
+				keys = append(keys, key)
+			}
+			for key := range r {
+				if _, ok := l[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				diffValue(path+"."+key, l[key], r[key], lines)
+			}
+			return
+		}
+
+	case []interface{}:
+		if r, ok := right.([]interface{}); ok {
+			for i := 0; i < len(l) || i < len(r); i++ {
+				var lv, rv interface{}
+				if i < len(l) {
+					lv = l[i]
+				}
+				if i < len(r) {
+					rv = r[i]
+				}
+
+				diffValue(path+"["+strconv.Itoa(i)+"]", lv, rv, lines)
+			}
+			return
+		}
+	}
+
+	if left != nil {
+		*lines = append(*lines, fmt.Sprintf("- %s: %s", path, jsonString(left)))
+	}
+	if right != nil {
+		*lines = append(*lines, fmt.Sprintf("+ %s: %s", path, jsonString(right)))
+	}
+}
+
+func jsonString(value interface{}) string {
+	raw, _ := json.Marshal(value)
+	return string(raw)
+}
+
+// sha256Of the recorded payload was truncated, so compare by digest
+func sha256Of(message proto.Message) (string, error) {
+	raw, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal message err")
+	}
+
+	digest := sha256.Sum256(raw)
+	return hex.EncodeToString(digest[:]), nil
+}
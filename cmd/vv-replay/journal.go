@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bluekaki/vv/internal/protos/gen"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// filter the zero value matches all
+type filter struct {
+	method string          // glob of full method
+	codes  map[string]bool // grpc code name, e.g. OK, Internal
+	since  time.Time       // inclusive
+	until  time.Time       // exclusive
+}
+
+func (f *filter) match(journal *pb.Journal, ts time.Time) bool {
+	if f.method != "" {
+		if ok, _ := path.Match(f.method, journal.GetRequest().GetMethod()); !ok {
+			return false
+		}
+	}
+
+	if len(f.codes) > 0 && !f.codes[journal.GetResponse().GetCode()] {
+		return false
+	}
+
+	if !f.since.IsZero() && (ts.IsZero() || ts.Before(f.since)) {
+		return false
+	}
+
+	if !f.until.IsZero() && (ts.IsZero() || !ts.Before(f.until)) {
+		return false
+	}
+
+	return true
+}
+
+// readJournals read JSON lines written by journal.NewFileSink (raw journal) or journal.NewZapSink ({"journal": ...}),
+// the line not a journal skipped, e.g. the other logs of the application sharing the zap logger.
+func readJournals(reader io.Reader, resolver *registry, each func(line int, journal *pb.Journal, ts time.Time) error) error {
+	unmarshal := protojson.UnmarshalOptions{Resolver: resolver, DiscardUnknown: true}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Bytes()
+		if len(strings.TrimSpace(string(raw))) == 0 {
+			continue
+		}
+
+		fields := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			continue // not json, e.g. plain text log
+		}
+
+		entry := json.RawMessage(raw)
+		if nested, ok := fields["journal"]; ok {
+			entry = nested
+		}
+
+		journal := new(pb.Journal)
+		if err := unmarshal.Unmarshal(entry, journal); err != nil || journal.GetRequest().GetMethod() == "" {
+			continue // e.g. the ts of zap (epoch seconds or ISO8601) is not a Timestamp
+		}
+
+		ts := journal.GetTs().AsTime()
+		if journal.GetTs() == nil {
+			ts = zapTime(fields["ts"])
+		}
+
+		if err := each(line, journal, ts); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(scanner.Err(), "read journal err")
+}
+
+// zapTime the ts of zap, epoch seconds or ISO8601
+func zapTime(raw json.RawMessage) time.Time {
+	if len(raw) == 0 {
+		return time.Time{}
+	}
+
+	var epoch float64
+	if err := json.Unmarshal(raw, &epoch); err == nil {
+		sec := int64(epoch)
+		return time.Unix(sec, int64((epoch-float64(sec))*1e9))
+	}
+
+	var iso string
+	if err := json.Unmarshal(raw, &iso); err == nil {
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700"} {
+			if ts, err := time.Parse(layout, iso); err == nil {
+				return ts
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+func parseTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	if ts, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return ts, nil
+	}
+
+	if epoch, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(epoch, 0), nil
+	}
+
+	return time.Time{}, errors.Errorf("time %s neither RFC3339 nor unix seconds", raw)
+}
+
+func fullMethodName(fullMethod string) string {
+	return strings.Replace(strings.TrimPrefix(fullMethod, "/"), "/", ".", 1)
+}
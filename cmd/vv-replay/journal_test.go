@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluekaki/vv/builder/journal"
+	"github.com/bluekaki/vv/internal/protos/gen"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newTestRegistry() *registry {
+	return &registry{files: new(protoregistry.Files), types: new(protoregistry.Types)}
+}
+
+func newTestJournal(t *testing.T, id, method string) *pb.Journal {
+	payload, err := anypb.New(wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &pb.Journal{
+		Id:      id,
+		Ts:      timestamppb.New(time.Unix(1697000000, 0)),
+		Request: &pb.Request{Method: method, Payload: payload},
+		Response: &pb.Response{
+			Code: "OK",
+		},
+		Success: true,
+	}
+}
+
+func TestReadJournals(t *testing.T) {
+	zapLog := new(bytes.Buffer)
+	for _, encoderConfig := range []zapcore.EncoderConfig{zap.NewProductionEncoderConfig(), zap.NewDevelopmentEncoderConfig()} {
+		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		if zapLog.Len() == 0 {
+			encoderConfig.EncodeTime = zapcore.EpochTimeEncoder
+		}
+
+		logger := zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(zapLog), zap.DebugLevel))
+		logger.Info("server started", zap.String("addr", ":8000"))
+		journal.NewZapSink(logger).Write(newTestJournal(t, "zap"+encoderConfig.TimeKey, "/rpc.HelloService/Unary"))
+		logger.Warn("something else")
+	}
+
+	raw, err := protojson.Marshal(newTestJournal(t, "file", "/rpc.HelloService/Unary"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		input string
+		ids   []string
+	}{
+		{
+			name:  "mixed zap log",
+			input: zapLog.String(),
+			ids:   []string{"zapts", "zapT"},
+		},
+		{
+			name:  "file sink",
+			input: string(raw) + "\n",
+			ids:   []string{"file"},
+		},
+		{
+			name:  "zap line without journal",
+			input: `{"level":"info","ts":1697000000.123,"msg":"x"}` + "\n" + `{"level":"info","ts":"2023-10-11T04:53:20.123Z","msg":"x"}`,
+		},
+		{
+			name:  "plain text, blank and journal without method",
+			input: "plain text\n\n" + `{"id":"no-method"}` + "\n" + `{"journal":{"id":"no-method"}}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var ids []string
+			err := readJournals(strings.NewReader(c.input), newTestRegistry(), func(line int, journal *pb.Journal, ts time.Time) error {
+				ids = append(ids, journal.GetId())
+				if !ts.Equal(time.Unix(1697000000, 0)) {
+					t.Errorf("line %d ts %v", line, ts)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Join(ids, ",") != strings.Join(c.ids, ",") {
+				t.Errorf("got %v, want %v", ids, c.ids)
+			}
+		})
+	}
+}
+
+func TestZapTime(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want time.Time
+	}{
+		{raw: ``, want: time.Time{}},
+		{raw: `1697000000.5`, want: time.Unix(1697000000, 5e8)},
+		{raw: `"2023-10-11T04:53:20.000Z"`, want: time.Unix(1697000000, 0)},
+		{raw: `"2023-10-11T12:53:20.000+0800"`, want: time.Unix(1697000000, 0)},
+		{raw: `"yesterday"`, want: time.Time{}},
+	}
+
+	for _, c := range cases {
+		if got := zapTime([]byte(c.raw)); !got.Equal(c.want) {
+			t.Errorf("zapTime(%s) = %v, want %v", c.raw, got, c.want)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	journal := newTestJournal(t, "x", "/rpc.HelloService/Unary")
+	ts := time.Unix(1697000000, 0)
+
+	cases := []struct {
+		name   string
+		filter *filter
+		want   bool
+	}{
+		{name: "zero", filter: &filter{}, want: true},
+		{name: "method glob", filter: &filter{method: "/rpc.HelloService/*"}, want: true},
+		{name: "method mismatch", filter: &filter{method: "/rpc.Other/*"}, want: false},
+		{name: "code", filter: &filter{codes: map[string]bool{"OK": true}}, want: true},
+		{name: "code mismatch", filter: &filter{codes: map[string]bool{"Internal": true}}, want: false},
+		{name: "since inclusive", filter: &filter{since: ts}, want: true},
+		{name: "until exclusive", filter: &filter{until: ts}, want: false},
+	}
+
+	for _, c := range cases {
+		if got := c.filter.match(journal, ts); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
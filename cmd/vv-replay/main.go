@@ -0,0 +1,386 @@
+// vv-replay re-issues the requests recorded in vv journals against a target server,
+// and diffs the new responses against the recorded ones, e.g. reproduce production bugs in staging.
+//
+// The journal lines written by journal.NewFileSink or journal.NewZapSink are both accepted,
+// the payloads are decoded by the descriptor set generated with:
+//
+//	protoc --include_imports --descriptor_set_out=api.pb *.proto
+//
+// Usage:
+//
+//	vv-replay -descriptor_set api.pb -target 127.0.0.1:8000 -method '/rpc.HelloService/*' -code Internal journal.log
+//
+// Only unary calls are replayed, and the requests of grpc-gateway are replayed as grpc calls.
+//
+// The requests with options.sensitive field(s) are skipped, and the sensitive field(s) of responses not compared,
+// as they have been redacted in journal. So are authorization and proxy-authorization by default
+// (see server.WithRedactedMetadata): -sign original and the recorded authorization work only if the server
+// journals them unredacted, otherwise pass -sign exec and -header authorization=... instead.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bluekaki/vv/builder/client"
+	"github.com/bluekaki/vv/internal/interceptor"
+	"github.com/bluekaki/vv/internal/protos/gen"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// headers repeatable -header key=value
+type headers []string
+
+func (h *headers) String() string {
+	return strings.Join(*h, ",")
+}
+
+func (h *headers) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return errors.Errorf("header %s should be key=value", value)
+	}
+
+	*h = append(*h, value)
+	return nil
+}
+
+func main() {
+	var extraHeaders headers
+
+	descriptorSet := flag.String("descriptor_set", "", "the FileDescriptorSet of services, protoc --include_imports --descriptor_set_out (required)")
+	target := flag.String("target", "", "the address replayed against, e.g. 127.0.0.1:8000 (required)")
+	method := flag.String("method", "", "only replay the full method(s) matched the glob, e.g. '/rpc.HelloService/*'")
+	codes := flag.String("code", "", "only replay the recorded code(s), comma separated, e.g. Internal,Unknown")
+	since := flag.String("since", "", "only replay the call(s) started at or after it, RFC3339 or unix seconds")
+	until := flag.String("until", "", "only replay the call(s) started before it, RFC3339 or unix seconds")
+	limit := flag.Int("limit", 0, "stop after replayed so many call(s), 0 no limit")
+	signMode := flag.String("sign", signNone, "how to sign the request: none, original (the recorded signature, only if the server journals proxy-authorization unredacted) or exec (fresh by -sign_cmd)")
+	signCmd := flag.String("sign_cmd", "", "split by white space, called with full method as the last argument and message on stdin, prints proxy-authorization and date in two lines")
+	timeout := flag.Duration("timeout", 10*time.Second, "the timeout of every call")
+	useTLS := flag.Bool("tls", false, "dial target by tls with system roots")
+	serverName := flag.String("tls_server_name", "", "override the server name of tls")
+	flag.Var(&extraHeaders, "header", "metadata key=value sent along with every call, repeatable, overrides the recorded one")
+	flag.Parse()
+
+	consistent, err := run(*descriptorSet, *target, *method, *codes, *since, *until, *limit, *signMode, *signCmd, *timeout, *useTLS, *serverName, extraHeaders, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if !consistent {
+		os.Exit(1)
+	}
+}
+
+func run(descriptorSet, target, method, codeList, since, until string, limit int, signMode, signCmd string, timeout time.Duration, useTLS bool, serverName string, extraHeaders headers, files []string) (consistent bool, err error) {
+	if descriptorSet == "" || target == "" {
+		return false, errors.New("-descriptor_set and -target required")
+	}
+
+	resolver, err := loadRegistry(descriptorSet)
+	if err != nil {
+		return false, err
+	}
+
+	f := &filter{method: method, codes: make(map[string]bool)}
+	for _, code := range strings.Split(codeList, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			f.codes[code] = true
+		}
+	}
+	if f.since, err = parseTime(since); err != nil {
+		return false, err
+	}
+	if f.until, err = parseTime(until); err != nil {
+		return false, err
+	}
+
+	signer, err := newSigner(signMode, signCmd)
+	if err != nil {
+		return false, err
+	}
+
+	options := []client.Option{client.WithDialTimeout(timeout)}
+	if signer.mode != signNone {
+		options = append(options, client.WithSign(signer.sign))
+	}
+	if useTLS {
+		options = append(options, client.WithCredential(credentials.NewClientTLSFromCert(nil, serverName)))
+	}
+
+	conn, err := client.New(target, options...)
+	if err != nil {
+		return false, errors.Wrapf(err, "dial %s err", target)
+	}
+	defer conn.Close()
+
+	r := &replayer{
+		registry: resolver,
+		conn:     conn,
+		signer:   signer,
+		headers:  make(metadata.MD),
+		timeout:  timeout,
+		out:      os.Stdout,
+	}
+	for _, header := range extraHeaders {
+		kv := strings.SplitN(header, "=", 2)
+		r.headers.Append(kv[0], kv[1])
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	errLimit := errors.New("limit reached")
+	for _, file := range files {
+		err = readFile(file, func(reader io.Reader) error {
+			return readJournals(reader, resolver, func(line int, journal *pb.Journal, ts time.Time) error {
+				if !f.match(journal, ts) {
+					return nil
+				}
+
+				r.replay(fmt.Sprintf("%s:%d", file, line), journal)
+				if limit > 0 && r.replayed >= limit {
+					return errLimit
+				}
+				return nil
+			})
+		})
+		if err == errLimit {
+			break
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "read %s err", file)
+		}
+	}
+
+	fmt.Fprintf(r.out, "\nreplayed: %d, matched: %d, differed: %d, failed: %d, skipped: %d\n",
+		r.replayed, r.matched, r.differed, r.failed, r.skipped)
+
+	return r.differed == 0 && r.failed == 0, nil
+}
+
+// readFile read the file (- stdin), which closed once read
+func readFile(file string, read func(reader io.Reader) error) error {
+	if file == "-" {
+		return read(os.Stdin)
+	}
+
+	fd, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	return read(fd)
+}
+
+type replayer struct {
+	registry *registry
+	conn     *grpc.ClientConn
+	signer   *signer
+	headers  metadata.MD
+	timeout  time.Duration
+	out      io.Writer
+
+	replayed, matched, differed, failed, skipped int
+}
+
+func (r *replayer) replay(position string, journal *pb.Journal) {
+	title := fmt.Sprintf("%s [%s] %s", position, journal.GetId(), journal.GetRequest().GetMethod())
+
+	if journal.GetStream() != nil {
+		r.skipped++
+		fmt.Fprintf(r.out, "%s SKIPPED: stream not supported\n", title)
+		return
+	}
+
+	if r.sensitive(journal.GetRequest().GetMethod(), protoreflect.MethodDescriptor.Input) {
+		r.skipped++
+		fmt.Fprintf(r.out, "%s SKIPPED: request has options.sensitive field(s), redacted in journal\n", title)
+		return
+	}
+
+	r.replayed++
+	diffs, newJournalID, err := r.call(journal)
+	if err != nil {
+		r.failed++
+		fmt.Fprintf(r.out, "%s FAILED: %v\n", title, err)
+		return
+	}
+
+	var note string
+	if r.sensitive(journal.GetRequest().GetMethod(), protoreflect.MethodDescriptor.Output) {
+		note = " (options.sensitive field(s) of response not compared)"
+	}
+
+	if len(diffs) == 0 {
+		r.matched++
+		fmt.Fprintf(r.out, "%s MATCHED [%s]%s\n", title, newJournalID, note)
+		return
+	}
+
+	r.differed++
+	fmt.Fprintf(r.out, "%s DIFFERED [%s]%s\n", title, newJournalID, note)
+	for _, diff := range diffs {
+		fmt.Fprintf(r.out, "    %s\n", diff)
+	}
+}
+
+// sensitive the input (or output) of method has sensitive field(s), which masked, hashed or dropped in journal,
+// so the request not replayable, and the response compared without them
+func (r *replayer) sensitive(fullMethod string, message func(protoreflect.MethodDescriptor) protoreflect.MessageDescriptor) bool {
+	method, err := r.registry.method(fullMethod)
+	if err != nil {
+		return false // reported as failed by call
+	}
+
+	return interceptor.Redactor.Sensitive(message(method))
+}
+
+// call replay the request, returns the differences and the journal id of replayed call
+func (r *replayer) call(journal *pb.Journal) ([]string, string, error) {
+	fullMethod := journal.GetRequest().GetMethod()
+
+	method, err := r.registry.method(fullMethod)
+	if err != nil {
+		return nil, "", err
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, "", errors.New("stream not supported")
+	}
+
+	req, err := r.message(journal.GetRequest().GetPayload(), method.Input())
+	if err != nil {
+		return nil, "", errors.Wrap(err, "request")
+	}
+
+	meta := make(metadata.MD)
+	for key, values := range r.headers {
+		meta.Set(key, values...)
+	}
+	if len(meta.Get(interceptor.Authorization)) == 0 {
+		authorization, err := recorded(journal, interceptor.Authorization)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "pass -header authorization=... instead")
+		}
+		if authorization != "" {
+			meta.Set(interceptor.Authorization, authorization)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), meta), r.timeout)
+	defer cancel()
+
+	r.signer.current, r.signer.err = journal, nil
+	resp := dynamicpb.NewMessage(method.Output())
+
+	var header metadata.MD
+	err = r.conn.Invoke(ctx, fullMethod, req, resp, grpc.Header(&header))
+	if r.signer.err != nil {
+		return nil, "", errors.Wrap(r.signer.err, "sign")
+	}
+
+	var newJournalID string
+	if values := header.Get(runtime.MetadataHeaderPrefix + interceptor.JournalID); len(values) > 0 {
+		newJournalID = values[0]
+	}
+
+	diffs, diffErr := r.diff(journal.GetResponse(), method.Output(), resp, err)
+	return diffs, newJournalID, diffErr
+}
+
+// message unpack payload of journal as the type of descriptor, empty message if no payload
+func (r *replayer) message(payload *anypb.Any, descriptor protoreflect.MessageDescriptor) (proto.Message, error) {
+	if payload.GetTypeUrl() == "" {
+		return dynamicpb.NewMessage(descriptor), nil
+	}
+
+	message, err := r.registry.unpack(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if truncated, ok := message.(*pb.Truncated); ok {
+		return nil, errors.Errorf("payload truncated in journal, size: %d sha256: %s", truncated.Size, truncated.Sha256)
+	}
+
+	if message.ProtoReflect().Descriptor().FullName() != descriptor.FullName() {
+		return nil, errors.Errorf("payload %s is not %s", message.ProtoReflect().Descriptor().FullName(), descriptor.FullName())
+	}
+
+	return message, nil
+}
+
+// diff the replayed response against the recorded one
+func (r *replayer) diff(recorded *pb.Response, descriptor protoreflect.MessageDescriptor, resp proto.Message, err error) ([]string, error) {
+	var diffs []string
+
+	if code := status.Code(err).String(); code != recorded.GetCode() {
+		diffs = append(diffs, fmt.Sprintf("code: %s => %s", recorded.GetCode(), code))
+	}
+
+	if err != nil {
+		if message := status.Convert(err).Message(); message != recorded.GetMessage() {
+			diffs = append(diffs, fmt.Sprintf("message: %q => %q", recorded.GetMessage(), message))
+		}
+		return diffs, nil
+	}
+
+	if recorded.GetCode() != codes.OK.String() {
+		return diffs, nil
+	}
+
+	if payload := recorded.GetPayload(); payload != nil {
+		message, err := r.registry.unpack(payload)
+		if err != nil {
+			return nil, errors.Wrap(err, "recorded response")
+		}
+
+		if truncated, ok := message.(*pb.Truncated); ok {
+			if interceptor.Redactor.Sensitive(descriptor) {
+				return diffs, nil // the digest is of the redacted one
+			}
+
+			digest, err := sha256Of(resp)
+			if err != nil {
+				return nil, err
+			}
+
+			if digest != truncated.Sha256 {
+				diffs = append(diffs, fmt.Sprintf("payload sha256: %s => %s", truncated.Sha256, digest))
+			}
+			return diffs, nil
+		}
+	}
+
+	expected, err := r.message(recorded.GetPayload(), descriptor)
+	if err != nil {
+		return nil, errors.Wrap(err, "recorded response")
+	}
+
+	// the sensitive fields have been redacted in journal
+	payloadDiffs, err := diffMessage(r.registry, interceptor.Redactor.Strip(expected), interceptor.Redactor.Strip(resp))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(diffs, payloadDiffs...), nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bluekaki/vv/internal/interceptor"
+	"github.com/bluekaki/vv/internal/protos/gen"
+	testpb "github.com/bluekaki/vv/test/testdata/pb/gen"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestDiffSensitive(t *testing.T) {
+	r := &replayer{registry: newTestRegistry()}
+	descriptor := new(testpb.HelloRequest).ProtoReflect().Descriptor()
+
+	recorded := &testpb.HelloRequest{Nick: "a", Mobile: "13800001111"}
+	payload, err := anypb.New(interceptor.Redactor.Redact(recorded, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name  string
+		resp  proto.Message
+		diffs []string
+	}{
+		{
+			name: "sensitive field redacted in journal",
+			resp: &testpb.HelloRequest{Nick: "a", Mobile: "13800001111"},
+		},
+		{
+			name: "sensitive field changed",
+			resp: &testpb.HelloRequest{Nick: "a", Mobile: "13900002222"},
+		},
+		{
+			name:  "plain field changed",
+			resp:  &testpb.HelloRequest{Nick: "b", Mobile: "13800001111"},
+			diffs: []string{"- payload.nick: \"a\"", "+ payload.nick: \"b\""},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			diffs, err := r.diff(&pb.Response{Code: "OK", Payload: payload}, descriptor, c.resp, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Join(diffs, "\n") != strings.Join(c.diffs, "\n") {
+				t.Errorf("got %q, want %q", diffs, c.diffs)
+			}
+		})
+	}
+}
+
+func TestRecorded(t *testing.T) {
+	cases := []struct {
+		value string
+		err   bool
+	}{
+		{value: ""},
+		{value: "Bearer token"},
+		{value: interceptor.RedactedValue, err: true},
+		{value: interceptor.TruncatedPrefix + " size=1024 sha256=00", err: true},
+	}
+
+	for _, c := range cases {
+		journal := &pb.Journal{Request: &pb.Request{Metadata: map[string]string{interceptor.Authorization: c.value}}}
+
+		value, err := recorded(journal, interceptor.Authorization)
+		if (err != nil) != c.err {
+			t.Errorf("recorded(%q) err %v", c.value, err)
+		}
+		if err == nil && value != c.value {
+			t.Errorf("recorded(%q) = %q", c.value, value)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails" // the details of status
+)
+
+// registry the types of descriptor set, fallback to the global one (vv internal, well-known & errdetails)
+type registry struct {
+	files *protoregistry.Files
+	types *protoregistry.Types
+}
+
+func loadRegistry(descriptorSet string) (*registry, error) {
+	raw, err := ioutil.ReadFile(descriptorSet)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s err", descriptorSet)
+	}
+
+	set := new(descriptorpb.FileDescriptorSet)
+	if err = proto.Unmarshal(raw, set); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal %s err", descriptorSet)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s illegal, generated by protoc --include_imports --descriptor_set_out?", descriptorSet)
+	}
+
+	r := &registry{files: files, types: new(protoregistry.Types)}
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		err = r.registerMessages(file.Messages())
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *registry) registerMessages(messages protoreflect.MessageDescriptors) error {
+	for i := 0; i < messages.Len(); i++ {
+		message := messages.Get(i)
+		if message.IsMapEntry() {
+			continue
+		}
+
+		if err := r.types.RegisterMessage(dynamicpb.NewMessageType(message)); err != nil {
+			return errors.Wrapf(err, "register %s err", message.FullName())
+		}
+
+		if err := r.registerMessages(message.Messages()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// method find by grpc full method, e.g. /pkg.Service/Method
+func (r *registry) method(fullMethod string) (protoreflect.MethodDescriptor, error) {
+	name := protoreflect.FullName(fullMethodName(fullMethod))
+
+	descriptor, err := r.files.FindDescriptorByName(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s not found in descriptor set", fullMethod)
+	}
+
+	method, ok := descriptor.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, errors.Errorf("%s not a method", fullMethod)
+	}
+
+	return method, nil
+}
+
+// unpack the message in any
+func (r *registry) unpack(any *anypb.Any) (proto.Message, error) {
+	messageType, err := r.FindMessageByURL(any.GetTypeUrl())
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s not found", any.GetTypeUrl())
+	}
+
+	message := messageType.New().Interface()
+	if err = (proto.UnmarshalOptions{Resolver: r}).Unmarshal(any.GetValue(), message); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal %s err", any.GetTypeUrl())
+	}
+
+	return message, nil
+}
+
+func (r *registry) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	if messageType, err := r.types.FindMessageByName(name); err == nil {
+		return messageType, nil
+	}
+	return protoregistry.GlobalTypes.FindMessageByName(name)
+}
+
+func (r *registry) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	if messageType, err := r.types.FindMessageByURL(url); err == nil {
+		return messageType, nil
+	}
+	return protoregistry.GlobalTypes.FindMessageByURL(url)
+}
+
+func (r *registry) FindExtensionByName(name protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	if extensionType, err := r.types.FindExtensionByName(name); err == nil {
+		return extensionType, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByName(name)
+}
+
+func (r *registry) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	if extensionType, err := r.types.FindExtensionByNumber(message, field); err == nil {
+		return extensionType, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"github.com/bluekaki/vv/internal/interceptor"
+	"github.com/bluekaki/vv/internal/protos/gen"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	signNone     = "none"
+	signOriginal = "original"
+	signExec     = "exec"
+)
+
+// signer sign the replayed request, fresh by command or reuse the recorded signature
+type signer struct {
+	mode    string
+	command []string
+	current *pb.Journal // the journal being replayed
+	err     error       // the error of signing current one
+}
+
+func newSigner(mode, command string) (*signer, error) {
+	s := &signer{mode: mode}
+
+	switch mode {
+	case signNone, signOriginal:
+	case signExec:
+		if s.command = strings.Fields(command); len(s.command) == 0 {
+			return nil, errors.New("-sign_cmd required by -sign=exec")
+		}
+	default:
+		return nil, errors.Errorf("-sign %s illegal, should be one of none, original and exec", mode)
+	}
+
+	return s, nil
+}
+
+// recorded the value of metadata in journal, error if it has been redacted or truncated
+func recorded(journal *pb.Journal, key string) (string, error) {
+	value := journal.GetRequest().GetMetadata()[key]
+	if value == interceptor.RedactedValue || strings.HasPrefix(value, interceptor.TruncatedPrefix) {
+		return "", errors.Errorf("the recorded %s has been redacted or truncated by the server's journal", key)
+	}
+
+	return value, nil
+}
+
+func (s *signer) sign(fullMethod string, message []byte) (auth, date string, err error) {
+	defer func() {
+		s.err = err
+	}()
+
+	if s.mode == signOriginal {
+		if auth, err = recorded(s.current, interceptor.ProxyAuthorization); err != nil {
+			return "", "", errors.Wrap(err, "-sign=original works only if it journaled unredacted, replay with -sign=exec instead")
+		}
+
+		date, err = recorded(s.current, interceptor.Date)
+		return
+	}
+
+	// the command (split by white space, no shell quoting) called with full method as the last argument,
+	// message on stdin, and prints the proxy-authorization on the first line, the date on the second line.
+	cmd := exec.Command(s.command[0], append(s.command[1:], fullMethod)...)
+	cmd.Stdin = bytes.NewReader(message)
+
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", "", errors.Wrapf(err, "sign command err: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	if scanner.Scan() {
+		auth = strings.TrimSpace(scanner.Text())
+	}
+	if scanner.Scan() {
+		date = strings.TrimSpace(scanner.Text())
+	}
+
+	if auth == "" || date == "" {
+		return "", "", errors.New("sign command should print proxy-authorization and date in two lines")
+	}
+
+	return auth, date, nil
+}
@@ -0,0 +1,36 @@
+package interceptor
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc/stats"
+)
+
+var _ stats.Handler = (*attemptsStatsHandler)(nil)
+
+// attemptsCounter mark the *uint32 counter of attempts in context
+type attemptsCounter struct{}
+
+// attemptsStatsHandler every attempt (including the retries) ends with a stats.End, count them into the counter in context
+type attemptsStatsHandler struct{}
+
+func (attemptsStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (attemptsStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	if _, ok := rs.(*stats.End); !ok {
+		return
+	}
+
+	if counter, ok := ctx.Value(attemptsCounter{}).(*uint32); ok {
+		atomic.AddUint32(counter, 1)
+	}
+}
+
+func (attemptsStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (attemptsStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
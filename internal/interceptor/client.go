@@ -4,60 +4,206 @@ import (
 	"context"
 	"fmt"
 	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/bluekaki/vv/internal/protos/gen"
+	"github.com/bluekaki/vv/internal/tracing"
+	"github.com/bluekaki/vv/options"
 
 	protoV1 "github.com/golang/protobuf/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/koketama/pbutil"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Sign signs the message
 type Sign func(fullMethod string, message []byte) (auth, date string, err error)
 
-// NewClientInterceptor create a client interceptor
-func NewClientInterceptor(sign Sign) *ClientInterceptor {
-	return &ClientInterceptor{sign: sign}
+// NewClientInterceptor create a client interceptor, journal nil means journal disabled
+func NewClientInterceptor(sign Sign, signStreamMessage bool, exporter tracing.Exporter, journal *JournalConfig) *ClientInterceptor {
+	interceptor := &ClientInterceptor{
+		sign:              sign,
+		signStreamMessage: signStreamMessage,
+	}
+
+	if exporter != nil {
+		interceptor.tracer = tracing.NewTracer(exporter, nil)
+	}
+
+	if journal != nil {
+		interceptor.journal = newJournalBuilder(journal)
+	}
+
+	return interceptor
 }
 
 // ClientInterceptor the client's interceptor
 type ClientInterceptor struct {
-	sign Sign
+	sign              Sign
+	signStreamMessage bool
+	tracer            *tracing.Tracer // nil when tracing disabled
+	journal           *journalBuilder // nil when journal disabled
+}
+
+// StatsHandler counts the attempts of journaled calls, nil when journal disabled
+func (c *ClientInterceptor) StatsHandler() stats.Handler {
+	if c.journal == nil {
+		return nil
+	}
+
+	return attemptsStatsHandler{}
+}
+
+func (c *ClientInterceptor) signMessage(fullMethod string, message interface{}) (signature, date string, err error) {
+	var raw string
+	if message != nil {
+		if raw, err = pbutil.ProtoMessage2JSON(message.(protoV1.Message)); err != nil {
+			return
+		}
+	}
+
+	return c.sign(fullMethod, []byte(raw))
+}
+
+func (c *ClientInterceptor) signContext(ctx context.Context, fullMethod string, message interface{}) (context.Context, error) {
+	signature, date, err := c.signMessage(fullMethod, message)
+	if err != nil {
+		return ctx, err
+	}
+
+	meta, _ := metadata.FromOutgoingContext(ctx)
+	if meta == nil {
+		meta = make(metadata.MD)
+	}
+
+	meta.Set(Date, date)
+	meta.Set(ProxyAuthorization, signature)
+	return metadata.NewOutgoingContext(ctx, meta), nil
+}
+
+// propagateJournalID copy the journal id of incoming context into outgoing metadata, unless already set
+func propagateJournalID(ctx context.Context) context.Context {
+	outgoing, _ := metadata.FromOutgoingContext(ctx)
+	if len(outgoing.Get(JournalID)) > 0 {
+		return ctx
+	}
+
+	incoming, _ := metadata.FromIncomingContext(ctx)
+	id := incoming.Get(JournalID)
+	if len(id) == 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, JournalID, id[0])
+}
+
+// clientCall the outbound call being journaled
+type clientCall struct {
+	journal    *journalBuilder
+	journalID  string
+	fullMethod string
+	target     string
+	ts         time.Time
+	attempts   *uint32
+}
+
+// startJournal nil if journal disabled or the method's options.journal is false, the journal id sent along with the call
+func (c *ClientInterceptor) startJournal(ctx context.Context, cc *grpc.ClientConn, fullMethod string) (context.Context, *clientCall) {
+	if c.journal == nil || !proto.GetExtension(FileDescriptor.LookupOptions(fullMethod), options.E_Journal).(bool) {
+		return ctx, nil
+	}
+
+	call := &clientCall{
+		journal:    c.journal,
+		fullMethod: fullMethod,
+		target:     cc.Target(),
+		ts:         time.Now(),
+		attempts:   new(uint32),
+	}
+
+	outgoing, _ := metadata.FromOutgoingContext(ctx)
+	if id := outgoing.Get(JournalID); len(id) > 0 {
+		call.journalID = id[0]
+	} else {
+		call.journalID = NewJournalID()
+		ctx = metadata.AppendToOutgoingContext(ctx, JournalID, call.journalID)
+	}
+
+	return context.WithValue(ctx, attemptsCounter{}, call.attempts), call
+}
+
+// write the journal marked by pb.Client, ctx holds the outgoing metadata and header is the response's
+func (c *clientCall) write(ctx context.Context, header metadata.MD, req, resp interface{}, stream *pb.Stream, err error) {
+	sampling, sampled := sampleJournal(FileDescriptor.LookupJournalSampling(c.fullMethod), err, time.Since(c.ts))
+	if !sampled {
+		return
+	}
+
+	outgoing, _ := metadata.FromOutgoingContext(ctx)
+	journal := c.journal.build(c.journalID, c.fullMethod, false, outgoing, req, resp, err)
+	journal.Ts = timestamppb.New(c.ts)
+	journal.CostSeconds = time.Since(c.ts).Seconds()
+	journal.Sampling = sampling
+	journal.Stream = stream
+	journal.Client = &pb.Client{
+		Target:   c.target,
+		Attempts: atomic.LoadUint32(c.attempts),
+	}
+	if id := header.Get(runtime.MetadataHeaderPrefix + JournalID); len(id) > 0 {
+		journal.Client.ServerJournalId = id[0]
+	}
+
+	c.journal.write(journal)
 }
 
 // UnaryInterceptor a interceptor for client unary operations
 func (c *ClientInterceptor) UnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+	var (
+		span   *tracing.Span
+		call   *clientCall
+		header metadata.MD
+	)
 	defer func() {
 		if p := recover(); p != nil {
 			s, _ := status.New(codes.Internal, fmt.Sprintf("%+v", p)).WithDetails(&pb.Stack{Info: string(debug.Stack())})
 			err = s.Err()
 		}
-	}()
 
-	if c.sign != nil {
-		var raw string
-		if req != nil {
-			if raw, err = pbutil.ProtoMessage2JSON(req.(protoV1.Message)); err != nil {
-				return
-			}
+		if span != nil {
+			endSpan(span, err)
 		}
 
-		var signature, date string
-		if signature, date, err = c.sign(method, []byte(raw)); err != nil {
-			return
+		if call != nil {
+			var resp interface{}
+			if err == nil {
+				resp = reply
+			}
+			call.write(ctx, header, req, resp, nil, err)
 		}
+	}()
 
-		meta, _ := metadata.FromOutgoingContext(ctx)
-		if meta == nil {
-			meta = make(metadata.MD)
-		}
+	ctx = propagateJournalID(ctx)
+	ctx, call = c.startJournal(ctx, cc, method)
+	if call != nil {
+		opts = append(opts, grpc.Header(&header))
+	}
 
-		meta.Set(Date, date)
-		meta.Set(ProxyAuthorization, signature)
-		ctx = metadata.NewOutgoingContext(ctx, meta)
+	if c.tracer != nil {
+		ctx, span = startClientSpan(ctx, c.tracer, method)
+	}
+
+	if c.sign != nil {
+		if ctx, err = c.signContext(ctx, method, req); err != nil {
+			return
+		}
 	}
 
 	return invoker(ctx, method, req, reply, cc, opts...)
@@ -65,29 +211,159 @@ func (c *ClientInterceptor) UnaryInterceptor(ctx context.Context, method string,
 
 type clientWrappedStream struct {
 	grpc.ClientStream
+	ctx           context.Context // holds the outgoing metadata
+	fullMethod    string
+	sign          Sign          // not nil when every outbound message should be signed
+	span          *tracing.Span // not nil when tracing enabled
+	serverStreams bool
+
+	sync.Mutex
+	call   *clientCall // not nil when journal enabled
+	stream pb.Stream
+	once   sync.Once
+}
+
+func (c *clientWrappedStream) record(direction pb.StreamMessage_Direction, m interface{}) {
+	if c.call == nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if direction == pb.StreamMessage_INBOUND {
+		c.stream.Received++
+	} else {
+		c.stream.Sent++
+	}
+
+	c.call.journal.appendMessage(&c.stream, direction, m)
+}
+
+// finish end the span and write the journal, only once, by RecvMsg or watch whichever first
+func (c *clientWrappedStream) finish(err error) {
+	c.once.Do(func() {
+		if c.span != nil {
+			endSpan(c.span, err)
+		}
+
+		if c.call != nil {
+			var header metadata.MD
+			if c.ClientStream != nil {
+				header, _ = c.ClientStream.Header()
+			}
+
+			c.Lock()
+			stream := &pb.Stream{
+				Messages: c.stream.Messages,
+				Received: c.stream.Received,
+				Sent:     c.stream.Sent,
+				Dropped:  c.stream.Dropped,
+			}
+			c.Unlock()
+
+			c.call.write(c.ctx, header, nil, nil, stream, err)
+		}
+	})
 }
 
 func (c *clientWrappedStream) RecvMsg(m interface{}) error {
-	return c.ClientStream.RecvMsg(m)
+	err := c.ClientStream.RecvMsg(m)
+	if err == nil {
+		c.record(pb.StreamMessage_INBOUND, m)
+	}
+
+	if finished, err := streamFinished(c.serverStreams, err); finished {
+		c.finish(err)
+	}
+
+	return err
 }
 
-func (c *clientWrappedStream) SendMsg(m interface{}) error {
-	return c.ClientStream.SendMsg(m)
+func (c *clientWrappedStream) SendMsg(m interface{}) (err error) {
+	if c.sign == nil {
+		if err = c.ClientStream.SendMsg(m); err == nil {
+			c.record(pb.StreamMessage_OUTBOUND, m)
+		}
+		return
+	}
+
+	raw, err := pbutil.ProtoMessage2JSON(m.(protoV1.Message))
+	if err != nil {
+		return err
+	}
+
+	signature, date, err := c.sign(c.fullMethod, []byte(raw))
+	if err != nil {
+		return err
+	}
+
+	err = c.ClientStream.SendMsg(&signedEnvelope{
+		message:            m,
+		date:               date,
+		proxyAuthorization: signature,
+	})
+	if err == nil {
+		c.record(pb.StreamMessage_OUTBOUND, m)
+	}
+
+	return err
 }
 
 // StreamInterceptor a interceptor for client stream operations
 func (c *ClientInterceptor) StreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (stream grpc.ClientStream, err error) {
+	wrappedStream := &clientWrappedStream{fullMethod: method, serverStreams: desc.ServerStreams}
 	defer func() {
 		if p := recover(); p != nil {
 			s, _ := status.New(codes.Internal, fmt.Sprintf("%+v", p)).WithDetails(&pb.Stack{Info: string(debug.Stack())})
 			err = s.Err()
 		}
+
+		wrappedStream.ctx = ctx // with the outgoing metadata signed
+		if err != nil {
+			wrappedStream.finish(err)
+		} else if wrappedStream.span != nil || wrappedStream.call != nil {
+			go wrappedStream.watch(ctx)
+		}
 	}()
 
+	ctx = propagateJournalID(ctx)
+	ctx, wrappedStream.call = c.startJournal(ctx, cc, method)
+
+	if c.tracer != nil {
+		ctx, wrappedStream.span = startClientSpan(ctx, c.tracer, method)
+	}
+
+	if c.sign != nil {
+		// the stream opens without any message, so only sign the full method (with date)
+		if ctx, err = c.signContext(ctx, method, nil); err != nil {
+			return
+		}
+
+		if c.signStreamMessage {
+			wrappedStream.sign = c.sign
+			opts = append(opts, grpc.CallContentSubtype(SignedCodecName))
+		}
+	}
+
 	stream, err = streamer(ctx, desc, cc, method, opts...)
 	if err != nil {
 		return
 	}
 
-	return &clientWrappedStream{ClientStream: stream}, nil
+	wrappedStream.ClientStream = stream
+	return wrappedStream, nil
+}
+
+// watch finish the stream cancelled or timed out by ctx, e.g. abandoned by the caller or never received till the end,
+// which RecvMsg doesn't tell.
+func (c *clientWrappedStream) watch(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-c.ClientStream.Context().Done(): // finished by itself, RecvMsg tells how unless ctx done as well
+	}
+
+	if err := ctx.Err(); err != nil {
+		c.finish(status.FromContextError(err).Err())
+	}
 }
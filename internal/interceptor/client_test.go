@@ -0,0 +1,114 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluekaki/vv/internal/tracing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+type spanRecorder struct {
+	sync.Mutex
+	spans []*tracing.Span
+}
+
+func (s *spanRecorder) Export(span *tracing.Span) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.spans = append(s.spans, span)
+	return nil
+}
+
+func (s *spanRecorder) Close() error {
+	return nil
+}
+
+func (s *spanRecorder) exported() []*tracing.Span {
+	s.Lock()
+	defer s.Unlock()
+
+	return append([]*tracing.Span{}, s.spans...)
+}
+
+// fakeClientStream RecvMsg returns recvErr, and the context of it done once the caller's done or RecvMsg returns
+type fakeClientStream struct {
+	grpc.ClientStream
+	ctx     context.Context
+	cancel  context.CancelFunc
+	recvErr error
+}
+
+func (f *fakeClientStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeClientStream) RecvMsg(interface{}) error {
+	f.cancel()
+	return f.recvErr
+}
+
+func TestClientStreamFinish(t *testing.T) {
+	cases := []struct {
+		name    string
+		timeout time.Duration
+		cancel  bool
+		recvErr error // RecvMsg called if not nil
+		code    codes.Code
+	}{
+		{name: "cancelled without recv", cancel: true, code: codes.Canceled},
+		{name: "timed out without recv", timeout: time.Millisecond, code: codes.DeadlineExceeded},
+		{name: "received till the end", recvErr: io.EOF, cancel: true, code: codes.OK},
+		{name: "received error", recvErr: io.ErrUnexpectedEOF, cancel: true, code: codes.Unknown},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			recorder := new(spanRecorder)
+			interceptor := NewClientInterceptor(nil, false, recorder, nil)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if c.timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), c.timeout)
+			}
+			defer cancel()
+
+			streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				streamCtx, streamCancel := context.WithCancel(ctx)
+				return &fakeClientStream{ctx: streamCtx, cancel: streamCancel, recvErr: c.recvErr}, nil
+			}
+
+			stream, err := interceptor.StreamInterceptor(ctx, &grpc.StreamDesc{ServerStreams: true}, nil, "/x.Y/Z", streamer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.recvErr != nil {
+				stream.RecvMsg(nil)
+			}
+			if c.cancel {
+				cancel()
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for len(recorder.exported()) == 0 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(time.Millisecond * 10) // no more than once
+
+			spans := recorder.exported()
+			if len(spans) != 1 {
+				t.Fatalf("%d span(s) exported, want 1", len(spans))
+			}
+			if code := spans[0].Attributes["rpc.grpc.status_code"]; code != int64(c.code) {
+				t.Errorf("code %v, want %d", code, c.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package interceptor
+
+import (
+	"strings"
+
+	"github.com/bluekaki/vv/internal/protos/gen"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/proto"
+	"google.golang.org/grpc/metadata"
+)
+
+// SignedCodecName the content-subtype of stream which every outbound message signed by client
+const SignedCodecName = "vv-signed"
+
+func init() {
+	encoding.RegisterCodec(new(signedCodec))
+}
+
+// signedEnvelope carry a message with its per-message signature header
+type signedEnvelope struct {
+	message            interface{}
+	date               string
+	proxyAuthorization string
+}
+
+// signedCodec client's messages are wrapped in pb.SignedMessage, server's messages stay as they are.
+type signedCodec struct{}
+
+func (s *signedCodec) Marshal(v interface{}) ([]byte, error) {
+	codec := encoding.GetCodec(proto.Name)
+
+	envelope, ok := v.(*signedEnvelope)
+	if !ok {
+		return codec.Marshal(v)
+	}
+
+	payload, err := codec.Marshal(envelope.message)
+	if err != nil {
+		return nil, err
+	}
+
+	return codec.Marshal(&pb.SignedMessage{
+		Payload:            payload,
+		Date:               envelope.date,
+		ProxyAuthorization: envelope.proxyAuthorization,
+	})
+}
+
+func (s *signedCodec) Unmarshal(data []byte, v interface{}) error {
+	codec := encoding.GetCodec(proto.Name)
+
+	envelope, ok := v.(*signedEnvelope)
+	if !ok {
+		return codec.Unmarshal(data, v)
+	}
+
+	message := new(pb.SignedMessage)
+	if err := codec.Unmarshal(data, message); err != nil {
+		return errors.Wrap(err, "unmarshal signed message err")
+	}
+
+	envelope.date = message.Date
+	envelope.proxyAuthorization = message.ProxyAuthorization
+	return codec.Unmarshal(message.Payload, envelope.message)
+}
+
+func (s *signedCodec) Name() string {
+	return SignedCodecName
+}
+
+func signedMessages(meta metadata.MD) bool {
+	values := meta.Get("content-type")
+	if len(values) == 0 {
+		return false
+	}
+
+	return strings.HasSuffix(values[0], "+"+SignedCodecName)
+}
@@ -0,0 +1,101 @@
+package interceptor
+
+import (
+	"testing"
+
+	"github.com/bluekaki/vv/internal/protos/gen"
+
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/proto"
+	"google.golang.org/grpc/metadata"
+	protoV2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestSignedCodec(t *testing.T) {
+	codec := encoding.GetCodec(SignedCodecName)
+	if codec == nil {
+		t.Fatal("signed codec not registered")
+	}
+
+	message := wrapperspb.String("hello")
+
+	cases := []struct {
+		name     string
+		envelope *signedEnvelope
+	}{
+		{name: "signed", envelope: &signedEnvelope{message: message, date: "Mon, 02 Jan 2006 15:04:05 GMT", proxyAuthorization: "signature"}},
+		{name: "signed without date", envelope: &signedEnvelope{message: message, proxyAuthorization: "signature"}},
+		{name: "unsigned"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var v interface{} = message
+			if c.envelope != nil {
+				v = c.envelope
+			}
+
+			raw, err := codec.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.envelope == nil {
+				plain, _ := encoding.GetCodec(proto.Name).Marshal(message)
+				if string(raw) != string(plain) {
+					t.Error("unsigned message should be marshaled as it is")
+				}
+
+				got := new(wrapperspb.StringValue)
+				if err = codec.Unmarshal(raw, got); err != nil || !protoV2.Equal(got, message) {
+					t.Errorf("unmarshal %v, err %v", got, err)
+				}
+				return
+			}
+
+			signedMessage := new(pb.SignedMessage)
+			if err = encoding.GetCodec(proto.Name).Unmarshal(raw, signedMessage); err != nil {
+				t.Fatal(err)
+			}
+			if signedMessage.Date != c.envelope.date || signedMessage.ProxyAuthorization != c.envelope.proxyAuthorization {
+				t.Errorf("signed message %v", signedMessage)
+			}
+
+			got := &signedEnvelope{message: new(wrapperspb.StringValue)}
+			if err = codec.Unmarshal(raw, got); err != nil {
+				t.Fatal(err)
+			}
+			if !protoV2.Equal(got.message.(*wrapperspb.StringValue), message) || got.date != c.envelope.date || got.proxyAuthorization != c.envelope.proxyAuthorization {
+				t.Errorf("got %+v, want %+v", got, c.envelope)
+			}
+		})
+	}
+
+	if err := codec.Unmarshal([]byte{0xff}, &signedEnvelope{message: new(wrapperspb.StringValue)}); err == nil {
+		t.Error("malformed signed message should fail")
+	}
+}
+
+func TestSignedMessages(t *testing.T) {
+	cases := []struct {
+		contentType string
+		want        bool
+	}{
+		{contentType: "", want: false},
+		{contentType: "application/grpc", want: false},
+		{contentType: "application/grpc+proto", want: false},
+		{contentType: "application/grpc+" + SignedCodecName, want: true},
+	}
+
+	for _, c := range cases {
+		meta := metadata.MD{}
+		if c.contentType != "" {
+			meta.Set("content-type", c.contentType)
+		}
+
+		if got := signedMessages(meta); got != c.want {
+			t.Errorf("signedMessages(%q) = %v, want %v", c.contentType, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,578 @@
+package interceptor
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bluekaki/vv/options"
+
+	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FieldValidator validate message by field options (options.require, options.eq ...)
+var FieldValidator = &fieldValidator{
+	rules: make(map[protoreflect.FullName]*messageRules),
+}
+
+type fieldValidator struct {
+	sync.RWMutex
+	rules map[protoreflect.FullName]*messageRules // message FullName : rules
+}
+
+type messageRules struct {
+	fields []*fieldRules
+	oneofs []protoreflect.OneofDescriptor // options.required
+}
+
+type fieldRules struct {
+	descriptor  protoreflect.FieldDescriptor
+	require     bool
+	comparisons []*comparison
+	nested      *messageRules // for message, the elements of list and the values of map
+
+	pattern     *regexp.Regexp
+	minLen      *uint64
+	maxLen      *uint64
+	minItems    *uint64
+	maxItems    *uint64
+	in          []protoreflect.Value
+	notIn       []protoreflect.Value
+	definedOnly bool
+	format      options.Format
+	ltNow       bool
+	gtNow       bool
+}
+
+func (r *fieldRules) empty() bool {
+	return !r.require && len(r.comparisons) == 0 && r.nested == nil &&
+		r.pattern == nil && r.minLen == nil && r.maxLen == nil && r.minItems == nil && r.maxItems == nil &&
+		len(r.in) == 0 && len(r.notIn) == 0 && !r.definedOnly && r.format == options.Format_UNKNOWN_FORMAT && !r.ltNow && !r.gtNow
+}
+
+type comparison struct {
+	operator string
+	raw      string
+	value    protoreflect.Value
+}
+
+// ParseP compile the rules of message (and it's nested), panic if options illegal
+func (f *fieldValidator) ParseP(descriptor protoreflect.MessageDescriptor) {
+	f.Lock()
+	defer f.Unlock()
+
+	if _, err := f.compile(descriptor); err != nil {
+		panic(fmt.Sprintf("%s field options illegal: %v", descriptor.FullName(), err))
+	}
+}
+
+func (f *fieldValidator) compile(descriptor protoreflect.MessageDescriptor) (*messageRules, error) {
+	if rules, ok := f.rules[descriptor.FullName()]; ok {
+		return rules, nil
+	}
+
+	rules := new(messageRules)
+	f.rules[descriptor.FullName()] = rules // placeholder for recursive message
+
+	fields := descriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		fieldOptions := field.Options()
+
+		fieldRules := &fieldRules{
+			descriptor: field,
+			require:    proto.GetExtension(fieldOptions, options.E_Require).(bool),
+		}
+
+		valueField := field
+		if field.IsMap() {
+			valueField = field.MapValue()
+		}
+
+		for _, extension := range []struct {
+			operator string
+			raw      string
+		}{
+			{"eq", proto.GetExtension(fieldOptions, options.E_Eq).(string)},
+			{"ne", proto.GetExtension(fieldOptions, options.E_Ne).(string)},
+			{"lt", proto.GetExtension(fieldOptions, options.E_Lt).(string)},
+			{"le", proto.GetExtension(fieldOptions, options.E_Le).(string)},
+			{"gt", proto.GetExtension(fieldOptions, options.E_Gt).(string)},
+			{"ge", proto.GetExtension(fieldOptions, options.E_Ge).(string)},
+		} {
+			if extension.raw == "" {
+				continue
+			}
+
+			value, err := parseValue(valueField, extension.raw)
+			if err != nil {
+				return nil, errors.Wrapf(err, "field %s options.%s", field.Name(), extension.operator)
+			}
+
+			fieldRules.comparisons = append(fieldRules.comparisons, &comparison{
+				operator: extension.operator,
+				raw:      extension.raw,
+				value:    value,
+			})
+		}
+
+		if err := fieldRules.compile(field, valueField); err != nil {
+			return nil, errors.Wrapf(err, "field %s", field.Name())
+		}
+
+		if message := valueField.Message(); message != nil && !isTimestamp(message) {
+			nested, err := f.compile(message)
+			if err != nil {
+				return nil, err
+			}
+			fieldRules.nested = nested
+		}
+
+		if !fieldRules.empty() {
+			rules.fields = append(rules.fields, fieldRules)
+		}
+	}
+
+	oneofs := descriptor.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		if oneof := oneofs.Get(i); proto.GetExtension(oneof.Options(), options.E_Required).(bool) {
+			rules.oneofs = append(rules.oneofs, oneof)
+		}
+	}
+
+	return rules, nil
+}
+
+func isTimestamp(message protoreflect.MessageDescriptor) bool {
+	return message.FullName() == "google.protobuf.Timestamp"
+}
+
+// compile the rules besides require & comparisons
+func (r *fieldRules) compile(field, valueField protoreflect.FieldDescriptor) error {
+	fieldOptions := field.Options()
+	kind := valueField.Kind()
+
+	if pattern := proto.GetExtension(fieldOptions, options.E_Pattern).(string); pattern != "" {
+		if kind != protoreflect.StringKind {
+			return errors.Errorf("options.pattern not supported by %s", kind)
+		}
+
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return errors.Wrap(err, "options.pattern")
+		}
+		r.pattern = regex
+	}
+
+	for _, extension := range []struct {
+		name   string
+		type_  protoreflect.ExtensionType
+		target **uint64
+		kinds  func() bool
+	}{
+		{"min_len", options.E_MinLen, &r.minLen, func() bool { return kind == protoreflect.StringKind || kind == protoreflect.BytesKind }},
+		{"max_len", options.E_MaxLen, &r.maxLen, func() bool { return kind == protoreflect.StringKind || kind == protoreflect.BytesKind }},
+		{"min_items", options.E_MinItems, &r.minItems, func() bool { return field.IsList() || field.IsMap() }},
+		{"max_items", options.E_MaxItems, &r.maxItems, func() bool { return field.IsList() || field.IsMap() }},
+	} {
+		if !proto.HasExtension(fieldOptions, extension.type_) {
+			continue
+		}
+		if !extension.kinds() {
+			return errors.Errorf("options.%s not supported by %s", extension.name, kind)
+		}
+
+		value := proto.GetExtension(fieldOptions, extension.type_).(uint64)
+		*extension.target = &value
+	}
+
+	for _, extension := range []struct {
+		name   string
+		type_  protoreflect.ExtensionType
+		target *[]protoreflect.Value
+	}{
+		{"in", options.E_In, &r.in},
+		{"not_in", options.E_NotIn, &r.notIn},
+	} {
+		for _, raw := range proto.GetExtension(fieldOptions, extension.type_).([]string) {
+			value, err := parseValue(valueField, raw)
+			if err != nil {
+				return errors.Wrapf(err, "options.%s", extension.name)
+			}
+			*extension.target = append(*extension.target, value)
+		}
+	}
+
+	if r.definedOnly = proto.GetExtension(fieldOptions, options.E_DefinedOnly).(bool); r.definedOnly && kind != protoreflect.EnumKind {
+		return errors.Errorf("options.defined_only not supported by %s", kind)
+	}
+
+	if r.format = proto.GetExtension(fieldOptions, options.E_Format).(options.Format); r.format != options.Format_UNKNOWN_FORMAT && kind != protoreflect.StringKind {
+		return errors.Errorf("options.format not supported by %s", kind)
+	}
+
+	r.ltNow = proto.GetExtension(fieldOptions, options.E_LtNow).(bool)
+	r.gtNow = proto.GetExtension(fieldOptions, options.E_GtNow).(bool)
+	if (r.ltNow || r.gtNow) && (valueField.Message() == nil || !isTimestamp(valueField.Message())) {
+		return errors.Errorf("options.lt_now & options.gt_now only supported by google.protobuf.Timestamp")
+	}
+
+	return nil
+}
+
+func parseValue(field protoreflect.FieldDescriptor, raw string) (protoreflect.Value, error) {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(raw)
+		return protoreflect.ValueOfBool(b), err
+
+	case protoreflect.EnumKind:
+		if value := field.Enum().Values().ByName(protoreflect.Name(raw)); value != nil {
+			return protoreflect.ValueOfInt64(int64(value.Number())), nil
+		}
+		i, err := strconv.ParseInt(raw, 10, 32)
+		return protoreflect.ValueOfInt64(i), err
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		return protoreflect.ValueOfInt64(i), err
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := strconv.ParseUint(raw, 10, 64)
+		return protoreflect.ValueOfUint64(u), err
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(raw, 64)
+		return protoreflect.ValueOfFloat64(f), err
+
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return protoreflect.ValueOfString(raw), nil
+	}
+
+	return protoreflect.Value{}, errors.Errorf("comparison not supported by %s", field.Kind())
+}
+
+// compare returns -1, 0, +1 like strings.Compare, the bound parsed by parseValue
+func compare(field protoreflect.FieldDescriptor, value, bound protoreflect.Value) int {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		if value.Bool() == bound.Bool() {
+			return 0
+		}
+		if !value.Bool() {
+			return -1
+		}
+		return 1
+
+	case protoreflect.EnumKind:
+		return compareInt64(int64(value.Enum()), bound.Int())
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return compareInt64(value.Int(), bound.Int())
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		switch {
+		case value.Uint() < bound.Uint():
+			return -1
+		case value.Uint() > bound.Uint():
+			return 1
+		}
+		return 0
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		switch {
+		case value.Float() < bound.Float():
+			return -1
+		case value.Float() > bound.Float():
+			return 1
+		}
+		return 0
+
+	case protoreflect.BytesKind:
+		return strings.Compare(string(value.Bytes()), bound.String())
+	}
+
+	return strings.Compare(value.String(), bound.String())
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (c *comparison) check(field protoreflect.FieldDescriptor, value protoreflect.Value) (description string, ok bool) {
+	result := compare(field, value, c.value)
+
+	switch c.operator {
+	case "eq":
+		return "must equal to " + c.raw, result == 0
+	case "ne":
+		return "must not equal to " + c.raw, result != 0
+	case "lt":
+		return "must be less than " + c.raw, result < 0
+	case "le":
+		return "must be less than or equal to " + c.raw, result <= 0
+	case "gt":
+		return "must be greater than " + c.raw, result > 0
+	case "ge":
+		return "must be greater than or equal to " + c.raw, result >= 0
+	}
+
+	return "", true
+}
+
+func isZero(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return !value.Bool()
+	case protoreflect.EnumKind:
+		return value.Enum() == 0
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return value.Int() == 0
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return value.Uint() == 0
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return value.Float() == 0
+	case protoreflect.StringKind:
+		return value.String() == ""
+	case protoreflect.BytesKind:
+		return len(value.Bytes()) == 0
+	}
+
+	return false
+}
+
+// Valid validate message by compiled rules, message not parsed will be ignored
+func (f *fieldValidator) Valid(message proto.Message) []*errdetails.BadRequest_FieldViolation {
+	m := message.ProtoReflect()
+
+	f.RLock()
+	rules := f.rules[m.Descriptor().FullName()]
+	f.RUnlock()
+
+	if rules == nil {
+		return nil
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	rules.check("", m, &violations)
+	return violations
+}
+
+func (m *messageRules) check(prefix string, message protoreflect.Message, violations *[]*errdetails.BadRequest_FieldViolation) {
+	join := func(name protoreflect.Name) string {
+		if prefix == "" {
+			return string(name)
+		}
+		return prefix + "." + string(name)
+	}
+
+	for _, rules := range m.fields {
+		rules.check(join(rules.descriptor.Name()), message, violations)
+	}
+
+	for _, oneof := range m.oneofs {
+		if message.WhichOneof(oneof) == nil {
+			violate(violations, join(oneof.Name()), "one of the fields required")
+		}
+	}
+}
+
+func inSet(field protoreflect.FieldDescriptor, value protoreflect.Value, set []protoreflect.Value) bool {
+	for _, v := range set {
+		if compare(field, value, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func rawSet(field protoreflect.FieldDescriptor, type_ protoreflect.ExtensionType) string {
+	return "[" + strings.Join(proto.GetExtension(field.Options(), type_).([]string), ", ") + "]"
+}
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func validFormat(format options.Format, value string) bool {
+	switch format {
+	case options.Format_EMAIL:
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+
+	case options.Format_URI:
+		uri, err := url.Parse(value)
+		return err == nil && uri.IsAbs()
+
+	case options.Format_UUID:
+		return uuidRegex.MatchString(value)
+
+	case options.Format_IP:
+		return net.ParseIP(value) != nil
+	}
+
+	return true
+}
+
+func timestampOf(message protoreflect.Message) time.Time {
+	fields := message.Descriptor().Fields()
+	return time.Unix(message.Get(fields.ByName("seconds")).Int(), message.Get(fields.ByName("nanos")).Int())
+}
+
+// violate record the violation of field on path, the description without the path
+func violate(violations *[]*errdetails.BadRequest_FieldViolation, path, description string) {
+	*violations = append(*violations, &errdetails.BadRequest_FieldViolation{
+		Field:       path,
+		Description: description,
+	})
+}
+
+// checkValue the single field, the element of list or the value of map
+func (r *fieldRules) checkValue(path string, field protoreflect.FieldDescriptor, value protoreflect.Value, violations *[]*errdetails.BadRequest_FieldViolation) {
+	for _, comparison := range r.comparisons {
+		if description, ok := comparison.check(field, value); !ok {
+			violate(violations, path, description)
+		}
+	}
+
+	if r.pattern != nil && !r.pattern.MatchString(value.String()) {
+		violate(violations, path, "must match pattern "+r.pattern.String())
+	}
+
+	if r.minLen != nil || r.maxLen != nil {
+		var length uint64
+		if field.Kind() == protoreflect.BytesKind {
+			length = uint64(len(value.Bytes()))
+		} else {
+			length = uint64(utf8.RuneCountInString(value.String()))
+		}
+
+		if r.minLen != nil && length < *r.minLen {
+			violate(violations, path, fmt.Sprintf("length must be at least %d", *r.minLen))
+		}
+		if r.maxLen != nil && length > *r.maxLen {
+			violate(violations, path, fmt.Sprintf("length must be at most %d", *r.maxLen))
+		}
+	}
+
+	if len(r.in) > 0 && !inSet(field, value, r.in) {
+		violate(violations, path, "must be in "+rawSet(r.descriptor, options.E_In))
+	}
+	if len(r.notIn) > 0 && inSet(field, value, r.notIn) {
+		violate(violations, path, "must not be in "+rawSet(r.descriptor, options.E_NotIn))
+	}
+
+	if r.definedOnly && field.Enum().Values().ByNumber(value.Enum()) == nil {
+		violate(violations, path, "must be a defined enum value")
+	}
+
+	if r.format != options.Format_UNKNOWN_FORMAT && !validFormat(r.format, value.String()) {
+		violate(violations, path, "must be a valid "+strings.ToLower(r.format.String()))
+	}
+
+	if r.ltNow && !timestampOf(value.Message()).Before(time.Now()) {
+		violate(violations, path, "must be before now")
+	}
+	if r.gtNow && !timestampOf(value.Message()).After(time.Now()) {
+		violate(violations, path, "must be after now")
+	}
+
+	if r.nested != nil {
+		r.nested.check(path, value.Message(), violations)
+	}
+}
+
+func (r *fieldRules) checkItems(path string, length int, violations *[]*errdetails.BadRequest_FieldViolation) {
+	if r.require && length == 0 {
+		violate(violations, path, "required")
+	}
+
+	if r.minItems != nil && uint64(length) < *r.minItems {
+		violate(violations, path, fmt.Sprintf("must have at least %d items", *r.minItems))
+	}
+	if r.maxItems != nil && uint64(length) > *r.maxItems {
+		violate(violations, path, fmt.Sprintf("must have at most %d items", *r.maxItems))
+	}
+}
+
+func (r *fieldRules) check(path string, message protoreflect.Message, violations *[]*errdetails.BadRequest_FieldViolation) {
+	field := r.descriptor
+
+	switch {
+	case field.IsMap():
+		mp := message.Get(field).Map()
+		r.checkItems(path, mp.Len(), violations)
+
+		for _, key := range sortedKeys(mp) { // the violations in a stable order
+			r.checkValue(fmt.Sprintf("%s[%q]", path, key.String()), field.MapValue(), mp.Get(key), violations)
+		}
+
+	case field.IsList():
+		list := message.Get(field).List()
+		r.checkItems(path, list.Len(), violations)
+
+		for i := 0; i < list.Len(); i++ {
+			r.checkValue(fmt.Sprintf("%s[%d]", path, i), field, list.Get(i), violations)
+		}
+
+	case field.Message() != nil:
+		if !message.Has(field) {
+			if r.require {
+				violate(violations, path, "required")
+			}
+			return
+		}
+
+		r.checkValue(path, field, message.Get(field), violations)
+
+	default:
+		value := message.Get(field)
+		if r.require && isZero(field, value) {
+			violate(violations, path, "required")
+			return
+		}
+
+		r.checkValue(path, field, value, violations)
+	}
+}
+
+// sortedKeys the keys of map in ascending order
+func sortedKeys(mp protoreflect.Map) []protoreflect.MapKey {
+	keys := make([]protoreflect.MapKey, 0, mp.Len())
+	mp.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+
+	sort.Slice(keys, func(i, j int) bool {
+		switch a := keys[i].Interface().(type) {
+		case string:
+			return a < keys[j].String()
+		case bool:
+			return !a && keys[j].Bool()
+		case int32, int64:
+			return keys[i].Int() < keys[j].Int()
+		default:
+			return keys[i].Uint() < keys[j].Uint()
+		}
+	})
+	return keys
+}
@@ -0,0 +1,374 @@
+package interceptor
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bluekaki/vv/options"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	formOnce       sync.Once
+	formDescriptor protoreflect.MessageDescriptor
+)
+
+// newFormDescriptor message Form with the constraint vocabulary, built & parsed by FieldValidator once
+// as it caches the rules by the full name
+func newFormDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	formOnce.Do(func() {
+		formDescriptor = buildValidatorFile(t, "form", formMessages()).Messages().ByName("Form")
+		FieldValidator.ParseP(formDescriptor)
+	})
+	return formDescriptor
+}
+
+func validatorField(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, set func(*descriptorpb.FieldOptions)) *descriptorpb.FieldDescriptorProto {
+	fieldOptions := new(descriptorpb.FieldOptions)
+	if set != nil {
+		set(fieldOptions)
+	}
+
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     kind.Enum(),
+		Options:  fieldOptions,
+	}
+}
+
+func formMessages() []*descriptorpb.DescriptorProto {
+	const (
+		typeString  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		typeInt32   = descriptorpb.FieldDescriptorProto_TYPE_INT32
+		typeEnum    = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+		typeMessage = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	repeated := func(field *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		field.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		return field
+	}
+	typed := func(field *descriptorpb.FieldDescriptorProto, typeName string) *descriptorpb.FieldDescriptorProto {
+		field.TypeName = proto.String(typeName)
+		return field
+	}
+	oneof := func(field *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		field.OneofIndex = proto.Int32(0)
+		return field
+	}
+
+	oneofOptions := new(descriptorpb.OneofOptions)
+	proto.SetExtension(oneofOptions, options.E_Required, true)
+
+	return []*descriptorpb.DescriptorProto{
+		{
+			Name: proto.String("Form"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				validatorField("name", 1, typeString, func(o *descriptorpb.FieldOptions) {
+					proto.SetExtension(o, options.E_Require, true)
+					proto.SetExtension(o, options.E_Pattern, "^[a-z]+$")
+					proto.SetExtension(o, options.E_MinLen, uint64(2))
+					proto.SetExtension(o, options.E_MaxLen, uint64(5))
+				}),
+				validatorField("age", 2, typeInt32, func(o *descriptorpb.FieldOptions) {
+					proto.SetExtension(o, options.E_Ge, "0")
+					proto.SetExtension(o, options.E_Lt, "150")
+				}),
+				validatorField("email", 3, typeString, func(o *descriptorpb.FieldOptions) {
+					proto.SetExtension(o, options.E_Format, options.Format_EMAIL)
+				}),
+				validatorField("role", 4, typeString, func(o *descriptorpb.FieldOptions) {
+					proto.SetExtension(o, options.E_In, []string{"admin", "user"})
+				}),
+				repeated(validatorField("tags", 5, typeString, func(o *descriptorpb.FieldOptions) {
+					proto.SetExtension(o, options.E_MinItems, uint64(1))
+					proto.SetExtension(o, options.E_MaxItems, uint64(2))
+					proto.SetExtension(o, options.E_NotIn, []string{"x"})
+				})),
+				repeated(typed(validatorField("scores", 6, typeMessage, func(o *descriptorpb.FieldOptions) {
+					proto.SetExtension(o, options.E_Gt, "0")
+				}), ".vv.test.form.Form.ScoresEntry")),
+				typed(validatorField("kind", 7, typeEnum, func(o *descriptorpb.FieldOptions) {
+					proto.SetExtension(o, options.E_DefinedOnly, true)
+				}), ".vv.test.form.Kind"),
+				typed(validatorField("child", 8, typeMessage, func(o *descriptorpb.FieldOptions) {
+					proto.SetExtension(o, options.E_Require, true)
+				}), ".vv.test.form.Child"),
+				typed(validatorField("at", 9, typeMessage, func(o *descriptorpb.FieldOptions) {
+					proto.SetExtension(o, options.E_LtNow, true)
+				}), ".google.protobuf.Timestamp"),
+				validatorField("code", 10, typeString, func(o *descriptorpb.FieldOptions) {
+					proto.SetExtension(o, options.E_Ne, "000")
+				}),
+				oneof(validatorField("phone", 11, typeString, nil)),
+				oneof(validatorField("mail", 12, typeString, nil)),
+			},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("ScoresEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					validatorField("key", 1, typeString, nil),
+					validatorField("value", 2, typeInt32, nil),
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+			OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("contact"), Options: oneofOptions}},
+		},
+		{
+			Name: proto.String("Child"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				validatorField("id", 1, typeString, func(o *descriptorpb.FieldOptions) {
+					proto.SetExtension(o, options.E_Require, true)
+				}),
+			},
+		},
+	}
+}
+
+// buildValidatorFile the file vv/test/<name>.proto of package vv.test.<name>, with enum Kind
+func buildValidatorFile(t *testing.T, name string, messages []*descriptorpb.DescriptorProto) protoreflect.FileDescriptor {
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("vv/test/" + name + ".proto"),
+		Package:    proto.String("vv.test." + name),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Kind"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("KIND_UNKNOWN"), Number: proto.Int32(0)},
+				{Name: proto.String("KIND_A"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: messages,
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+// newForm a valid Form, modified by mutate
+func newForm(t *testing.T, mutate func(form *dynamicpb.Message, set func(name string, value protoreflect.Value))) *dynamicpb.Message {
+	descriptor := newFormDescriptor(t)
+	form := dynamicpb.NewMessage(descriptor)
+	set := func(name string, value protoreflect.Value) {
+		form.Set(descriptor.Fields().ByName(protoreflect.Name(name)), value)
+	}
+
+	set("name", protoreflect.ValueOfString("abc"))
+	set("age", protoreflect.ValueOfInt32(30))
+	set("email", protoreflect.ValueOfString("a@b.co"))
+	set("role", protoreflect.ValueOfString("user"))
+	form.Mutable(descriptor.Fields().ByName("tags")).List().Append(protoreflect.ValueOfString("a"))
+	form.Mutable(descriptor.Fields().ByName("scores")).Map().Set(protoreflect.ValueOfString("m").MapKey(), protoreflect.ValueOfInt32(1))
+	set("kind", protoreflect.ValueOfEnum(1))
+	child := form.Mutable(descriptor.Fields().ByName("child")).Message()
+	child.Set(child.Descriptor().Fields().ByName("id"), protoreflect.ValueOfString("1"))
+	set("at", protoreflect.ValueOfMessage(timestamppb.New(time.Now().Add(-time.Hour)).ProtoReflect()))
+	set("phone", protoreflect.ValueOfString("10086"))
+
+	if mutate != nil {
+		mutate(form, set)
+	}
+	return form
+}
+
+func formatViolations(violations []*errdetails.BadRequest_FieldViolation) string {
+	var lines []string
+	for _, violation := range violations {
+		lines = append(lines, violation.Field+": "+violation.Description)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestFieldValidator(t *testing.T) {
+	descriptor := newFormDescriptor(t)
+	clear := func(name string) func(*dynamicpb.Message, func(string, protoreflect.Value)) {
+		return func(form *dynamicpb.Message, _ func(string, protoreflect.Value)) {
+			form.Clear(descriptor.Fields().ByName(protoreflect.Name(name)))
+		}
+	}
+	setString := func(name, value string) func(*dynamicpb.Message, func(string, protoreflect.Value)) {
+		return func(_ *dynamicpb.Message, set func(string, protoreflect.Value)) {
+			set(name, protoreflect.ValueOfString(value))
+		}
+	}
+	setInt32 := func(name string, value int32) func(*dynamicpb.Message, func(string, protoreflect.Value)) {
+		return func(_ *dynamicpb.Message, set func(string, protoreflect.Value)) {
+			set(name, protoreflect.ValueOfInt32(value))
+		}
+	}
+	setTags := func(tags ...string) func(*dynamicpb.Message, func(string, protoreflect.Value)) {
+		return func(form *dynamicpb.Message, _ func(string, protoreflect.Value)) {
+			field := descriptor.Fields().ByName("tags")
+			form.Clear(field)
+
+			list := form.Mutable(field).List()
+			for _, tag := range tags {
+				list.Append(protoreflect.ValueOfString(tag))
+			}
+		}
+	}
+
+	cases := []struct {
+		name   string
+		mutate func(form *dynamicpb.Message, set func(name string, value protoreflect.Value))
+		want   []string
+	}{
+		{name: "valid"},
+		{name: "require", mutate: clear("name"), want: []string{"name: required"}},
+		{name: "min_len", mutate: setString("name", "a"), want: []string{"name: length must be at least 2"}},
+		{name: "pattern & max_len", mutate: setString("name", "ABCDEFG"), want: []string{"name: must match pattern ^[a-z]+$", "name: length must be at most 5"}},
+		{name: "lt", mutate: setInt32("age", 150), want: []string{"age: must be less than 150"}},
+		{name: "ge", mutate: setInt32("age", -1), want: []string{"age: must be greater than or equal to 0"}},
+		{name: "format", mutate: setString("email", "a"), want: []string{"email: must be a valid email"}},
+		{name: "in", mutate: setString("role", "root"), want: []string{"role: must be in [admin, user]"}},
+		{name: "ne", mutate: setString("code", "000"), want: []string{"code: must not equal to 000"}},
+		{name: "min_items", mutate: setTags(), want: []string{"tags: must have at least 1 items"}},
+		{name: "max_items", mutate: setTags("a", "b", "c"), want: []string{"tags: must have at most 2 items"}},
+		{name: "not_in", mutate: setTags("a", "x"), want: []string{"tags[1]: must not be in [x]"}},
+		{
+			name: "map values in key order",
+			mutate: func(form *dynamicpb.Message, _ func(string, protoreflect.Value)) {
+				scores := form.Mutable(descriptor.Fields().ByName("scores")).Map()
+				for _, key := range []string{"d", "b", "c", "a"} {
+					scores.Set(protoreflect.ValueOfString(key).MapKey(), protoreflect.ValueOfInt32(0))
+				}
+			},
+			want: []string{`scores["a"]: must be greater than 0`, `scores["b"]: must be greater than 0`, `scores["c"]: must be greater than 0`, `scores["d"]: must be greater than 0`},
+		},
+		{name: "defined_only", mutate: func(_ *dynamicpb.Message, set func(string, protoreflect.Value)) {
+			set("kind", protoreflect.ValueOfEnum(7))
+		}, want: []string{"kind: must be a defined enum value"}},
+		{name: "require message", mutate: clear("child"), want: []string{"child: required"}},
+		{
+			name: "nested",
+			mutate: func(form *dynamicpb.Message, _ func(string, protoreflect.Value)) {
+				child := form.Mutable(descriptor.Fields().ByName("child")).Message()
+				child.Clear(child.Descriptor().Fields().ByName("id"))
+			},
+			want: []string{"child.id: required"},
+		},
+		{
+			name: "lt_now",
+			mutate: func(_ *dynamicpb.Message, set func(string, protoreflect.Value)) {
+				set("at", protoreflect.ValueOfMessage(timestamppb.New(time.Now().Add(time.Hour)).ProtoReflect()))
+			},
+			want: []string{"at: must be before now"},
+		},
+		{name: "oneof required", mutate: clear("phone"), want: []string{"contact: one of the fields required"}},
+		{
+			name: "descriptor order",
+			mutate: func(form *dynamicpb.Message, set func(string, protoreflect.Value)) {
+				form.Clear(descriptor.Fields().ByName("phone"))
+				set("kind", protoreflect.ValueOfEnum(7))
+				set("age", protoreflect.ValueOfInt32(-1))
+				form.Clear(descriptor.Fields().ByName("name"))
+			},
+			want: []string{"name: required", "age: must be greater than or equal to 0", "kind: must be a defined enum value", "contact: one of the fields required"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := formatViolations(FieldValidator.Valid(newForm(t, c.mutate)))
+			if want := strings.Join(c.want, "\n"); got != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestValidMessageBadRequest(t *testing.T) {
+	form := newForm(t, func(form *dynamicpb.Message, set func(string, protoreflect.Value)) {
+		set("age", protoreflect.ValueOfInt32(-1))
+		set("kind", protoreflect.ValueOfEnum(7))
+	})
+
+	s, _ := status.FromError(validMessage(form))
+	if s.Code() != codes.InvalidArgument || s.Message() != "age must be greater than or equal to 0" {
+		t.Fatalf("got %v %q", s.Code(), s.Message())
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range s.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations = badRequest.FieldViolations
+		}
+	}
+	if len(violations) != 2 || violations[0].Field != "age" || violations[1].Field != "kind" {
+		t.Errorf("got violations %v", violations)
+	}
+}
+
+func TestFieldValidatorIllegalOptions(t *testing.T) {
+	const (
+		typeString = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		typeInt32  = descriptorpb.FieldDescriptorProto_TYPE_INT32
+	)
+
+	cases := []struct {
+		name  string
+		field *descriptorpb.FieldDescriptorProto
+	}{
+		{name: "comparison not parsable", field: validatorField("f", 1, typeInt32, func(o *descriptorpb.FieldOptions) {
+			proto.SetExtension(o, options.E_Gt, "one")
+		})},
+		{name: "pattern not compilable", field: validatorField("f", 1, typeString, func(o *descriptorpb.FieldOptions) {
+			proto.SetExtension(o, options.E_Pattern, "(")
+		})},
+		{name: "pattern on int", field: validatorField("f", 1, typeInt32, func(o *descriptorpb.FieldOptions) {
+			proto.SetExtension(o, options.E_Pattern, ".")
+		})},
+		{name: "min_len on int", field: validatorField("f", 1, typeInt32, func(o *descriptorpb.FieldOptions) {
+			proto.SetExtension(o, options.E_MinLen, uint64(1))
+		})},
+		{name: "min_items on singular", field: validatorField("f", 1, typeString, func(o *descriptorpb.FieldOptions) {
+			proto.SetExtension(o, options.E_MinItems, uint64(1))
+		})},
+		{name: "in not parsable", field: validatorField("f", 1, typeInt32, func(o *descriptorpb.FieldOptions) {
+			proto.SetExtension(o, options.E_In, []string{"1", "two"})
+		})},
+		{name: "defined_only on string", field: validatorField("f", 1, typeString, func(o *descriptorpb.FieldOptions) {
+			proto.SetExtension(o, options.E_DefinedOnly, true)
+		})},
+		{name: "format on int", field: validatorField("f", 1, typeInt32, func(o *descriptorpb.FieldOptions) {
+			proto.SetExtension(o, options.E_Format, options.Format_UUID)
+		})},
+		{name: "lt_now on string", field: validatorField("f", 1, typeString, func(o *descriptorpb.FieldOptions) {
+			proto.SetExtension(o, options.E_LtNow, true)
+		})},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			file := buildValidatorFile(t, "illegal", []*descriptorpb.DescriptorProto{{
+				Name:  proto.String("Illegal"),
+				Field: []*descriptorpb.FieldDescriptorProto{c.field},
+			}})
+
+			defer func() {
+				if recover() == nil {
+					t.Error("should panic")
+				}
+			}()
+
+			validator := &fieldValidator{rules: make(map[protoreflect.FullName]*messageRules)}
+			validator.ParseP(file.Messages().Get(0))
+		})
+	}
+}
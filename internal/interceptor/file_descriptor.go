@@ -2,6 +2,7 @@ package interceptor
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/bluekaki/vv/options"
@@ -9,18 +10,25 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 const _ = grpc.SupportPackageIsVersion7
 
 // FileDescriptor protobuf file descriptor
 var FileDescriptor = &fileDescriptor{
-	options: make(map[string]protoreflect.ProtoMessage),
+	options:    make(map[string]protoreflect.ProtoMessage),
+	sampling:   make(map[string]*journalSampling),
+	buckets:    make(map[string][]float64),
+	objectives: make(map[string]*objective),
 }
 
 type fileDescriptor struct {
 	sync.RWMutex
-	options map[string]protoreflect.ProtoMessage // FullMethod : Options
+	options    map[string]protoreflect.ProtoMessage // FullMethod : Options
+	sampling   map[string]*journalSampling          // FullMethod : journal sampling
+	buckets    map[string][]float64                 // FullMethod : latency buckets
+	objectives map[string]*objective                // FullMethod : slo
 }
 
 func (f *fileDescriptor) ParseP(descriptor protoreflect.FileDescriptor) {
@@ -36,6 +44,7 @@ func (f *fileDescriptor) ParseP(descriptor protoreflect.FileDescriptor) {
 			method := methods.Get(k)
 			fullMethod := fmt.Sprintf("/%s/%s", serivce.FullName(), method.Name())
 			f.options[fullMethod] = method.Options()
+			FieldValidator.ParseP(method.Input())
 
 			if option := proto.GetExtension(method.Options(), options.E_Authorization).(*options.Handler); option != nil &&
 				Validator.AuthorizationValidator(option.Name) == nil {
@@ -46,6 +55,30 @@ func (f *fileDescriptor) ParseP(descriptor protoreflect.FileDescriptor) {
 				Validator.ProxyAuthorizationValidator(option.Name) == nil {
 				panic(fmt.Sprintf("%s options.proxy_authorization validator: [%s] not found", fullMethod, option.Name))
 			}
+
+			sampling, err := parseJournalSampling(method.Options())
+			if err != nil {
+				panic(fmt.Sprintf("%s %v", fullMethod, err))
+			}
+			if sampling != nil {
+				f.sampling[fullMethod] = sampling
+			}
+
+			buckets, err := parseLatencyBuckets(method.Options())
+			if err != nil {
+				panic(fmt.Sprintf("%s %v", fullMethod, err))
+			}
+			if buckets != nil {
+				f.buckets[fullMethod] = buckets
+			}
+
+			objective, err := parseObjective(method)
+			if err != nil {
+				panic(fmt.Sprintf("%s %v", fullMethod, err))
+			}
+			if objective != nil {
+				f.objectives[fullMethod] = objective
+			}
 		}
 	}
 }
@@ -56,3 +89,81 @@ func (f *fileDescriptor) Options(fullMethod string) protoreflect.ProtoMessage {
 
 	return f.options[fullMethod]
 }
+
+// LookupOptions the parsed one first, then fallback to protoregistry.GlobalFiles (e.g. on client side)
+func (f *fileDescriptor) LookupOptions(fullMethod string) protoreflect.ProtoMessage {
+	if methodOptions := f.Options(fullMethod); methodOptions != nil {
+		return methodOptions
+	}
+
+	name := strings.Replace(strings.TrimPrefix(fullMethod, "/"), "/", ".", 1)
+	descriptor, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil
+	}
+
+	if method, ok := descriptor.(protoreflect.MethodDescriptor); ok {
+		return method.Options()
+	}
+	return nil
+}
+
+// JournalSampling nil if the method journal every call
+func (f *fileDescriptor) JournalSampling(fullMethod string) *journalSampling {
+	f.RLock()
+	defer f.RUnlock()
+
+	return f.sampling[fullMethod]
+}
+
+// LookupJournalSampling the parsed one first, then fallback to the options of protoregistry.GlobalFiles the same as LookupOptions;
+// the illegal one of them journal every call.
+func (f *fileDescriptor) LookupJournalSampling(fullMethod string) *journalSampling {
+	f.RLock()
+	sampling, ok := f.sampling[fullMethod]
+	f.RUnlock()
+	if ok {
+		return sampling
+	}
+
+	if methodOptions := f.LookupOptions(fullMethod); methodOptions != nil {
+		sampling, _ = parseJournalSampling(methodOptions)
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if parsed, ok := f.sampling[fullMethod]; ok {
+		return parsed
+	}
+	f.sampling[fullMethod] = sampling // nil cached as well
+	return sampling
+}
+
+// LatencyBuckets nil if the method uses the default buckets
+func (f *fileDescriptor) LatencyBuckets(fullMethod string) []float64 {
+	f.RLock()
+	defer f.RUnlock()
+
+	return f.buckets[fullMethod]
+}
+
+// Objective nil if the method has no slo
+func (f *fileDescriptor) Objective(fullMethod string) *objective {
+	f.RLock()
+	defer f.RUnlock()
+
+	return f.objectives[fullMethod]
+}
+
+// Objectives all of the slo, FullMethod : slo
+func (f *fileDescriptor) Objectives() map[string]*objective {
+	f.RLock()
+	defer f.RUnlock()
+
+	objectives := make(map[string]*objective, len(f.objectives))
+	for fullMethod, objective := range f.objectives {
+		objectives[fullMethod] = objective
+	}
+	return objectives
+}
@@ -6,6 +6,7 @@ import (
 	"runtime/debug"
 
 	"github.com/bluekaki/vv/internal/protos/gen"
+	"github.com/bluekaki/vv/internal/tracing"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -41,32 +42,88 @@ func forwardedByGrpcGateway(meta metadata.MD) bool {
 }
 
 // NewGatewayInterceptor create a gateway interceptor
-func NewGatewayInterceptor() *GatewayInterceptor {
-	return new(GatewayInterceptor)
+func NewGatewayInterceptor(exporter tracing.Exporter) *GatewayInterceptor {
+	interceptor := new(GatewayInterceptor)
+	if exporter != nil {
+		interceptor.tracer = tracing.NewTracer(exporter, nil)
+	}
+
+	return interceptor
 }
 
 // GatewayInterceptor the gateway's interceptor
 type GatewayInterceptor struct {
+	tracer *tracing.Tracer // nil when tracing disabled
+}
+
+func (g *GatewayInterceptor) withHeader(ctx context.Context) context.Context {
+	meta, _ := metadata.FromOutgoingContext(ctx)
+	if meta == nil {
+		meta = make(metadata.MD)
+	}
+
+	// TODO verify auth in future
+
+	meta.Set(gwHeader.key, gwHeader.value)
+	return metadata.NewOutgoingContext(ctx, meta)
 }
 
 // UnaryInterceptor a interceptor for gateway unary operations
 func (g *GatewayInterceptor) UnaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+	var span *tracing.Span
 	defer func() {
 		if p := recover(); p != nil {
 			s, _ := status.New(codes.Internal, fmt.Sprintf("%+v", p)).WithDetails(&pb.Stack{Info: string(debug.Stack())})
 			err = s.Err()
 		}
+
+		if span != nil {
+			endSpan(span, err)
+		}
 	}()
 
-	meta, _ := metadata.FromOutgoingContext(ctx)
-	if meta == nil {
-		meta = make(metadata.MD)
+	if g.tracer != nil {
+		ctx, span = startClientSpan(ctx, g.tracer, method)
+		span.SetAttribute("vv.gateway", true)
 	}
 
-	// TODO verify auth in future
+	return invoker(g.withHeader(ctx), method, req, reply, cc, opts...)
+}
 
-	meta.Set(gwHeader.key, gwHeader.value)
-	ctx = metadata.NewOutgoingContext(ctx, meta)
+// StreamInterceptor a interceptor for gateway stream operations
+func (g *GatewayInterceptor) StreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (stream grpc.ClientStream, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			s, _ := status.New(codes.Internal, fmt.Sprintf("%+v", p)).WithDetails(&pb.Stack{Info: string(debug.Stack())})
+			err = s.Err()
+		}
+	}()
+
+	if g.tracer == nil {
+		return streamer(g.withHeader(ctx), desc, cc, method, opts...)
+	}
+
+	ctx, span := startClientSpan(ctx, g.tracer, method)
+	span.SetAttribute("vv.gateway", true)
+
+	stream, err = streamer(g.withHeader(ctx), desc, cc, method, opts...)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+
+	return &gatewayWrappedStream{ClientStream: stream, span: span, serverStreams: desc.ServerStreams}, nil
+}
+
+type gatewayWrappedStream struct {
+	grpc.ClientStream
+	span          *tracing.Span
+	serverStreams bool
+}
+
+func (g *gatewayWrappedStream) RecvMsg(m interface{}) error {
+	err := g.ClientStream.RecvMsg(m)
+	finishSpan(g.span, g.serverStreams, err)
 
-	return invoker(ctx, method, req, reply, cc, opts...)
+	return err
 }
@@ -0,0 +1,335 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bluekaki/vv/internal/protos/gen"
+
+	"github.com/koketama/minami58"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// JournalSink where the journal(s) written to
+type JournalSink interface {
+	// Write the journal, should be safe for concurrent use
+	Write(journal *pb.Journal) error
+	// Close flush and release resource(s)
+	Close() error
+}
+
+// ErrJournalDropped the journal discarded by sink because of overload, reported at most once per JournalDroppedInterval
+var ErrJournalDropped = errors.New("journal dropped, async sink buffer full")
+
+// JournalDroppedInterval the interval ErrJournalDropped reported at most once
+const JournalDroppedInterval = time.Second * 10
+
+// DefaultMaxStreamMessages the default count of messages journaled per stream, the rest counted as dropped
+const DefaultMaxStreamMessages = 100
+
+// JournalConfig how the journal built and where it written to
+type JournalConfig struct {
+	Sink              JournalSink
+	LoggedMetadata    []string // nil DefaultLoggedMetadata
+	RedactedMetadata  []string // value(s) replaced by RedactedValue
+	MaxPayloadBytes   int      // 0 no limit
+	MaxMetadataBytes  int      // 0 no limit
+	MaxStreamMessages int      // 0 DefaultMaxStreamMessages
+	// HashKey the key of HMAC-SHA256 hashing the HASH sensitive fields, nil a random one,
+	// which means the hash comparable only within the process
+	HashKey []byte
+	// TrustUpstreamID honour the journal_id in incoming metadata if returns true, nil never
+	TrustUpstreamID func(ctx context.Context) bool
+	// OnError report the error of writing journal, nil ignored
+	OnError func(journalID string, err error)
+}
+
+type journalBuilder struct {
+	sink              JournalSink
+	onError           func(journalID string, err error)
+	dropped           *DropReporter
+	loggedKeys        map[string]bool
+	redactedMetadata  map[string]bool
+	maxPayloadBytes   int
+	maxMetadataBytes  int
+	maxStreamMessages int
+	hashKey           []byte
+	trustUpstreamID   func(ctx context.Context) bool
+}
+
+func newJournalBuilder(config *JournalConfig) *journalBuilder {
+	loggedMetadata := config.LoggedMetadata
+	if loggedMetadata == nil {
+		loggedMetadata = DefaultLoggedMetadata()
+	}
+
+	maxStreamMessages := config.MaxStreamMessages
+	if maxStreamMessages <= 0 {
+		maxStreamMessages = DefaultMaxStreamMessages
+	}
+
+	hashKey := config.HashKey
+	if hashKey == nil {
+		hashKey = make([]byte, sha256.Size)
+		rand.Read(hashKey)
+	}
+
+	return &journalBuilder{
+		sink:              config.Sink,
+		onError:           config.OnError,
+		dropped:           NewDropReporter(config.OnError),
+		loggedKeys:        toSet(loggedMetadata),
+		redactedMetadata:  toSet(config.RedactedMetadata),
+		maxPayloadBytes:   config.MaxPayloadBytes,
+		maxMetadataBytes:  config.MaxMetadataBytes,
+		maxStreamMessages: maxStreamMessages,
+		hashKey:           hashKey,
+		trustUpstreamID:   config.TrustUpstreamID,
+	}
+}
+
+// NewJournalWriter write journal to sink, the error reported to onError the same as JournalConfig.OnError
+func NewJournalWriter(sink JournalSink, onError func(journalID string, err error)) func(journal *pb.Journal) {
+	return newJournalBuilder(&JournalConfig{Sink: sink, OnError: onError}).write
+}
+
+// write the journal to sink, ErrJournalDropped counted and reported at most once per JournalDroppedInterval
+func (j *journalBuilder) write(journal *pb.Journal) {
+	err := j.sink.Write(journal)
+	if err == nil || j.onError == nil {
+		return
+	}
+
+	if !journalDropped(err) {
+		j.onError(journal.Id, err)
+		return
+	}
+
+	j.dropped.Drop(journal.Id)
+}
+
+// DropReporter report the dropped journal(s) as ErrJournalDropped at most once per JournalDroppedInterval
+type DropReporter struct {
+	onError  func(journalID string, err error)
+	dropped  uint64 // count since last reported
+	reported int64  // unix nano
+}
+
+// NewDropReporter the dropped journal(s) reported to onError, nil ignored
+func NewDropReporter(onError func(journalID string, err error)) *DropReporter {
+	return &DropReporter{onError: onError}
+}
+
+// Drop count the journal dropped, and report the count if the interval elapsed
+func (d *DropReporter) Drop(journalID string) {
+	if d.onError == nil {
+		return
+	}
+
+	atomic.AddUint64(&d.dropped, 1)
+
+	now := time.Now().UnixNano()
+	last := atomic.LoadInt64(&d.reported)
+	if now-last < int64(JournalDroppedInterval) || !atomic.CompareAndSwapInt64(&d.reported, last, now) {
+		return
+	}
+
+	d.onError(journalID, errors.Wrapf(ErrJournalDropped, "%d journal(s) dropped since last reported", atomic.SwapUint64(&d.dropped, 0)))
+}
+
+func journalDropped(err error) bool {
+	for _, err := range multierr.Errors(err) {
+		if errors.Is(err, ErrJournalDropped) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewJournalID a random journal id
+func NewJournalID() string {
+	nonce := make([]byte, 16)
+	io.ReadFull(rand.Reader, nonce)
+
+	return string(minami58.Encode(nonce))
+}
+
+// validJournalID at most 64 of [0-9A-Za-z_-], so that a forged one can not pollute the journal
+func validJournalID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+
+	for _, c := range id {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '-') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// toSet the metadata keys in lower case
+func toSet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = true
+	}
+	return set
+}
+
+// loggedMetadata body redacts the gateway-forwarded body before it truncated
+func (j *journalBuilder) loggedMetadata(meta metadata.MD, body func(string) string) map[string]string {
+	var redactedByCaller map[string]bool
+	if values := meta.Get(RedactedKeys); len(values) > 0 { // only more redacted, so trust every caller
+		redactedByCaller = toSet(strings.Split(values[0], ","))
+	}
+
+	mp := make(map[string]string)
+	for key, values := range meta {
+		if !j.loggedKeys[key] || len(values) == 0 {
+			continue
+		}
+
+		value := values[0]
+		if key == Body {
+			value = body(value)
+		}
+
+		switch {
+		case value == "": // nothing to redact, e.g. the authorization absent from the gateway request
+			mp[key] = value
+
+		case j.redactedMetadata[key], redactedByCaller[key]:
+			mp[key] = RedactedValue
+
+		case j.maxMetadataBytes > 0 && len(value) > j.maxMetadataBytes:
+			digest := sha256.Sum256([]byte(value))
+			mp[key] = fmt.Sprintf("%s size=%d sha256=%s", TruncatedPrefix, len(value), hex.EncodeToString(digest[:]))
+
+		default:
+			mp[key] = value
+		}
+	}
+	return mp
+}
+
+// marshalAny the sensitive fields redacted, and replaced by pb.Truncated if oversized
+func (j *journalBuilder) marshalAny(m interface{}) *anypb.Any {
+	if m == nil {
+		return nil
+	}
+
+	any, _ := anypb.New(Redactor.Redact(m.(proto.Message), j.hashKey))
+	if any == nil || j.maxPayloadBytes <= 0 || len(any.Value) <= j.maxPayloadBytes {
+		return any
+	}
+
+	digest := sha256.Sum256(any.Value)
+	truncated, _ := anypb.New(&pb.Truncated{
+		Size:    uint64(len(any.Value)),
+		Sha256:  hex.EncodeToString(digest[:]),
+		TypeUrl: any.TypeUrl,
+	})
+	return truncated
+}
+
+// redactBody the body forwarded by gateway is the json of req (or the field of it by google.api.http body),
+// the sensitive fields of it redacted the same as the payload. It's replaced by RedactedValue if can not be
+// unmarshalled strictly, e.g. the body forwarded from upstream is not the one of this rpc.
+func (j *journalBuilder) redactBody(fullMethod string, restapi bool, req interface{}, body string) string {
+	if body == "" {
+		return body
+	}
+	if !restapi {
+		return RedactedValue
+	}
+
+	message, ok := req.(proto.Message)
+	if !ok || !Redactor.Sensitive(message.ProtoReflect().Descriptor()) {
+		return body
+	}
+
+	target := message.ProtoReflect().New()
+	if http := proto.GetExtension(FileDescriptor.Options(fullMethod), annotations.E_Http).(*annotations.HttpRule); http != nil && http.Body != "*" {
+		field := target.Descriptor().Fields().ByName(protoreflect.Name(http.Body))
+		if field == nil || field.Message() == nil || field.IsList() || field.IsMap() {
+			return RedactedValue
+		}
+		target = target.NewField(field).Message()
+	}
+
+	if err := protojson.Unmarshal([]byte(body), target.Interface()); err != nil {
+		return RedactedValue
+	}
+
+	raw, err := protojson.Marshal(Redactor.Redact(target.Interface(), j.hashKey))
+	if err != nil {
+		return RedactedValue
+	}
+	return string(raw)
+}
+
+// appendMessage the message of stream journaled, or counted as dropped beyond the max stream messages
+func (j *journalBuilder) appendMessage(stream *pb.Stream, direction pb.StreamMessage_Direction, m interface{}) {
+	if len(stream.Messages) >= j.maxStreamMessages {
+		stream.Dropped++
+		return
+	}
+
+	stream.Messages = append(stream.Messages, &pb.StreamMessage{
+		Direction: direction,
+		Ts:        timestamppb.Now(),
+		Payload:   j.marshalAny(m),
+	})
+}
+
+// build the journal of the rpc, meta is the incoming one on server side and the outgoing one on client side
+func (j *journalBuilder) build(journalID, fullMethod string, restapi bool, meta metadata.MD, req, resp interface{}, err error) *pb.Journal {
+	journal := &pb.Journal{
+		Id: journalID,
+		Request: &pb.Request{
+			Restapi: restapi,
+			Method:  fullMethod,
+			Metadata: j.loggedMetadata(meta, func(body string) string {
+				return j.redactBody(fullMethod, restapi, req, body)
+			}),
+			Payload: j.marshalAny(req),
+		},
+		Response: &pb.Response{
+			Code:    codes.OK.String(),
+			Payload: j.marshalAny(resp),
+		},
+		Success: err == nil,
+	}
+
+	if err != nil {
+		s, _ := status.FromError(err)
+		journal.Response.Code = s.Code().String()
+		journal.Response.Message = s.Message()
+
+		journal.Response.Details = make([]*anypb.Any, len(s.Details()))
+		for i, detail := range s.Details() {
+			journal.Response.Details[i], _ = anypb.New(detail.(proto.Message))
+		}
+	}
+
+	return journal
+}
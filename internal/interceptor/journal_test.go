@@ -0,0 +1,129 @@
+package interceptor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bluekaki/vv/internal/protos/gen"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestMarshalAnyTruncated(t *testing.T) {
+	message := wrapperspb.String(strings.Repeat("x", 32))
+	raw, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(raw)
+
+	cases := []struct {
+		name      string
+		max       int
+		truncated bool
+	}{
+		{name: "no limit", max: 0},
+		{name: "within limit", max: len(raw)},
+		{name: "oversized", max: len(raw) - 1, truncated: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			builder := newJournalBuilder(&JournalConfig{MaxPayloadBytes: c.max})
+			any := builder.marshalAny(message)
+
+			if !c.truncated {
+				got := new(wrapperspb.StringValue)
+				if err := any.UnmarshalTo(got); err != nil || !proto.Equal(got, message) {
+					t.Errorf("got %v, err %v", got, err)
+				}
+				return
+			}
+
+			truncated := new(pb.Truncated)
+			if err := any.UnmarshalTo(truncated); err != nil {
+				t.Fatal(err)
+			}
+
+			want := &pb.Truncated{
+				Size:    uint64(len(raw)),
+				Sha256:  hex.EncodeToString(digest[:]),
+				TypeUrl: "type.googleapis.com/google.protobuf.StringValue",
+			}
+			if !proto.Equal(truncated, want) {
+				t.Errorf("got %v, want %v", truncated, want)
+			}
+		})
+	}
+
+	if any := newJournalBuilder(&JournalConfig{MaxPayloadBytes: 1}).marshalAny(nil); any != nil {
+		t.Errorf("nil marshaled as %v", any)
+	}
+}
+
+func TestLoggedMetadataTruncated(t *testing.T) {
+	value := strings.Repeat("v", 16)
+	digest := sha256.Sum256([]byte(value))
+	marker := fmt.Sprintf("%s size=%d sha256=%s", TruncatedPrefix, len(value), hex.EncodeToString(digest[:]))
+
+	cases := []struct {
+		name string
+		max  int
+		meta metadata.MD
+		want map[string]string
+	}{
+		{name: "no limit", meta: metadata.Pairs(Date, value), want: map[string]string{Date: value}},
+		{name: "within limit", max: len(value), meta: metadata.Pairs(Date, value), want: map[string]string{Date: value}},
+		{name: "oversized", max: len(value) - 1, meta: metadata.Pairs(Date, value), want: map[string]string{Date: marker}},
+		{name: "redacted before truncated", max: 1, meta: metadata.Pairs(Authorization, value), want: map[string]string{Authorization: RedactedValue}},
+		{name: "empty kept", max: 1, meta: metadata.Pairs(Date, ""), want: map[string]string{Date: ""}},
+		{name: "body truncated after redacted", max: len(value) - 1, meta: metadata.Pairs(Body, "raw"), want: map[string]string{Body: marker}},
+		{name: "not logged", max: 1, meta: metadata.Pairs("x-unknown", value), want: map[string]string{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			builder := newJournalBuilder(&JournalConfig{
+				RedactedMetadata: []string{Authorization},
+				MaxMetadataBytes: c.max,
+			})
+
+			got := builder.loggedMetadata(c.meta, func(string) string { return value })
+			if fmt.Sprint(got) != fmt.Sprint(c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestAppendMessageDropped(t *testing.T) {
+	cases := []struct {
+		name     string
+		max      int
+		messages int
+		dropped  uint32
+	}{
+		{name: "within limit", max: 3, messages: 3},
+		{name: "beyond limit", max: 2, messages: 5, dropped: 3},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			builder := newJournalBuilder(&JournalConfig{MaxStreamMessages: c.max})
+
+			stream := new(pb.Stream)
+			for i := 0; i < c.messages; i++ {
+				builder.appendMessage(stream, pb.StreamMessage_INBOUND, wrapperspb.Int32(int32(i)))
+			}
+
+			if len(stream.Messages) != c.messages-int(c.dropped) || stream.Dropped != c.dropped {
+				t.Errorf("got %d messages %d dropped", len(stream.Messages), stream.Dropped)
+			}
+		})
+	}
+}
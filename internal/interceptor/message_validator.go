@@ -0,0 +1,52 @@
+package interceptor
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// validatable the Valid() generated by protoc-gen-message-validator
+type validatable interface {
+	Valid() error
+}
+
+// fieldError error with the field path
+type fieldError interface {
+	Field() string
+}
+
+// validMessage call the message's Valid() if implemented, then validate by field options,
+// returns InvalidArgument with google.rpc.BadRequest detail
+func validMessage(message interface{}) error {
+	if v, ok := message.(validatable); ok {
+		if err := v.Valid(); err != nil {
+			violation := &errdetails.BadRequest_FieldViolation{Description: err.Error()}
+			if field, ok := err.(fieldError); ok {
+				violation.Field = field.Field()
+			}
+
+			return badRequest(violation.Description, violation)
+		}
+	}
+
+	if m, ok := message.(proto.Message); ok {
+		if violations := FieldValidator.Valid(m); len(violations) > 0 {
+			return badRequest(violations[0].Field+" "+violations[0].Description, violations...)
+		}
+	}
+
+	return nil
+}
+
+// badRequest InvalidArgument with message, and the violations as google.rpc.BadRequest detail
+func badRequest(message string, violations ...*errdetails.BadRequest_FieldViolation) error {
+	s := status.New(codes.InvalidArgument, message)
+
+	if detailed, err := s.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+		s = detailed
+	}
+
+	return s.Err()
+}
@@ -1,35 +1,590 @@
 package interceptor
 
 import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 )
 
 const (
-	namespace = "bluekaki"
-	subsystem = "vv"
+	// DefaultMetricsNamespace the default namespace of metrics
+	DefaultMetricsNamespace = "bluekaki"
+	// DefaultMetricsSubsystem the default subsystem of metrics
+	DefaultMetricsSubsystem = "vv"
+	// DefaultErrorMessagesCapacity the default count of distinct error messages kept by Metrics.ErrorMessages
+	DefaultErrorMessagesCapacity = 100
+
+	// maxErrorMessageRunes the error message truncated in Metrics.ErrorMessages
+	maxErrorMessageRunes = 128
 )
 
-func init() {
-	prometheus.MustRegister(MetricsRequestCost)
-	prometheus.MustRegister(MetricsError)
-}
-
-// all metrics used by WithPrometheus & WithPrometheusPush
-
-// MetricsRequestCost metrics for ok request cost
-var MetricsRequestCost = prometheus.NewHistogramVec(prometheus.HistogramOpts{
-	Namespace: namespace,
-	Subsystem: subsystem,
-	Name:      "requestcost",
-	Help:      "[ok] request(s) cost seconds",
-	Buckets:   []float64{0.1, 0.3, 0.5, 0.7, 0.9, 1.1},
-}, []string{"method"})
-
-// MetricsError metrics for alertmanager
-var MetricsError = prometheus.NewHistogramVec(prometheus.HistogramOpts{
-	Namespace: namespace,
-	Subsystem: subsystem,
-	Name:      "error",
-	Help:      "error(s) alert",
-	Buckets:   []float64{0.1, 0.3, 0.5, 0.7, 0.9, 1.1},
-}, []string{"method", "code", "message", "journal_id"})
+// Metrics all metrics of a server, used by WithPrometheus & WithPrometheusPush
+type Metrics struct {
+	// Success metrics for ok request(s)
+	Success *prometheus.CounterVec
+	// RequestCost metrics for ok request cost
+	RequestCost *LatencyVec
+	// Error metrics for alertmanager, with journal_id exemplar
+	Error *prometheus.CounterVec
+	// ErrorCost metrics for error request cost, with journal_id exemplar
+	ErrorCost *LatencyVec
+	// ErrorMessages metrics for the top error messages
+	ErrorMessages *ErrorMessages
+	// StreamActive metrics for currently open stream(s)
+	StreamActive *prometheus.GaugeVec
+	// StreamReceived metrics for message(s) received by stream(s)
+	StreamReceived *prometheus.CounterVec
+	// StreamSent metrics for message(s) sent by stream(s)
+	StreamSent *prometheus.CounterVec
+	// StreamDuration metrics for stream(s) lifetime
+	StreamDuration *prometheus.HistogramVec
+	// SLOTotal metrics for request(s) of method with options.slo
+	SLOTotal *prometheus.CounterVec
+	// SLOGood metrics for good request(s) of method with options.slo
+	SLOGood *prometheus.CounterVec
+	// SLOObjectives metrics for options.slo
+	SLOObjectives *Objectives
+}
+
+// NewMetrics create the metrics and register them to registerer (if not nil),
+// the collector(s) already registered (e.g. by another server of same namespace & subsystem) are reused
+func NewMetrics(registerer prometheus.Registerer, namespace, subsystem string) (*Metrics, error) {
+	metrics := &Metrics{
+		Success: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "success_total",
+			Help:      "[ok] request(s)",
+		}, []string{"method"}),
+
+		RequestCost: NewLatencyVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requestcost",
+			Help:      "[ok] request(s) cost seconds",
+			Buckets:   []float64{0.1, 0.3, 0.5, 0.7, 0.9, 1.1},
+		}),
+
+		Error: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "error_total",
+			Help:      "error(s) alert",
+		}, []string{"method", "code"}),
+
+		ErrorCost: NewLatencyVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "errorcost",
+			Help:      "[error] request(s) cost seconds",
+			Buckets:   []float64{0.1, 0.3, 0.5, 0.7, 0.9, 1.1},
+		}, "code"),
+
+		ErrorMessages: NewErrorMessages(namespace, subsystem, DefaultErrorMessagesCapacity),
+
+		StreamActive: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "stream_active",
+			Help:      "currently open stream(s)",
+		}, []string{"method"}),
+
+		StreamReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "stream_received_total",
+			Help:      "message(s) received by stream(s)",
+		}, []string{"method"}),
+
+		StreamSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "stream_sent_total",
+			Help:      "message(s) sent by stream(s)",
+		}, []string{"method"}),
+
+		StreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "stream_duration_seconds",
+			Help:      "stream(s) lifetime seconds",
+			Buckets:   []float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600},
+		}, []string{"method"}),
+
+		SLOTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "slo_total",
+			Help:      "request(s) of method with slo",
+		}, []string{"method"}),
+
+		SLOGood: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "slo_good_total",
+			Help:      "good request(s) of method with slo, neither failed by server nor slower than the latency objective",
+		}, []string{"method"}),
+
+		SLOObjectives: NewObjectives(namespace, subsystem),
+	}
+
+	if registerer == nil {
+		return metrics, nil
+	}
+
+	var err error
+	if metrics.Success, err = registerCounter(registerer, metrics.Success); err != nil {
+		return nil, err
+	}
+	if metrics.RequestCost, err = registerLatency(registerer, metrics.RequestCost); err != nil {
+		return nil, err
+	}
+	if metrics.Error, err = registerCounter(registerer, metrics.Error); err != nil {
+		return nil, err
+	}
+	if metrics.ErrorCost, err = registerLatency(registerer, metrics.ErrorCost); err != nil {
+		return nil, err
+	}
+	if metrics.ErrorMessages, err = registerErrorMessages(registerer, metrics.ErrorMessages); err != nil {
+		return nil, err
+	}
+	if metrics.StreamActive, err = registerGauge(registerer, metrics.StreamActive); err != nil {
+		return nil, err
+	}
+	if metrics.StreamReceived, err = registerCounter(registerer, metrics.StreamReceived); err != nil {
+		return nil, err
+	}
+	if metrics.StreamSent, err = registerCounter(registerer, metrics.StreamSent); err != nil {
+		return nil, err
+	}
+	if metrics.StreamDuration, err = registerHistogram(registerer, metrics.StreamDuration); err != nil {
+		return nil, err
+	}
+	if metrics.SLOTotal, err = registerCounter(registerer, metrics.SLOTotal); err != nil {
+		return nil, err
+	}
+	if metrics.SLOGood, err = registerCounter(registerer, metrics.SLOGood); err != nil {
+		return nil, err
+	}
+	if metrics.SLOObjectives, err = registerObjectives(registerer, metrics.SLOObjectives); err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// Collectors all of the collector(s), e.g. for pushgateway
+func (m *Metrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.Success,
+		m.RequestCost,
+		m.Error,
+		m.ErrorCost,
+		m.ErrorMessages,
+		m.StreamActive,
+		m.StreamReceived,
+		m.StreamSent,
+		m.StreamDuration,
+		m.SLOTotal,
+		m.SLOGood,
+		m.SLOObjectives,
+	}
+}
+
+// ObserveSuccess count the ok request and it's cost
+func (m *Metrics) ObserveSuccess(fullMethod string, cost time.Duration) {
+	method := metricsMethod(fullMethod)
+
+	m.Success.WithLabelValues(method).Inc()
+	m.RequestCost.WithLabelValues(FileDescriptor.LatencyBuckets(fullMethod), method).Observe(cost.Seconds())
+	m.observeObjective(fullMethod, method, nil, cost)
+}
+
+// ObserveError count the error request and it's cost with journal id as exemplar,
+// the message goes to ErrorMessages only, so that the cardinality is bounded
+func (m *Metrics) ObserveError(fullMethod string, err error, journalID string, cost time.Duration) {
+	method := metricsMethod(fullMethod)
+	s := status.Convert(err)
+	code := s.Code().String()
+	exemplar := journalExemplar(journalID)
+
+	counter := m.Error.WithLabelValues(method, code)
+	if adder, ok := counter.(prometheus.ExemplarAdder); ok && exemplar != nil {
+		adder.AddWithExemplar(1, exemplar)
+	} else {
+		counter.Inc()
+	}
+
+	observer := m.ErrorCost.WithLabelValues(FileDescriptor.LatencyBuckets(fullMethod), method, code)
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && exemplar != nil {
+		exemplarObserver.ObserveWithExemplar(cost.Seconds(), exemplar)
+	} else {
+		observer.Observe(cost.Seconds())
+	}
+
+	message := s.Message()
+	if journalID != "" { // e.g. embedded by the panic, so that the same error counted as one
+		message = strings.Replace(message, journalID, "-", -1)
+	}
+
+	m.ErrorMessages.Inc(method, code, message)
+	m.observeObjective(fullMethod, method, err, cost)
+}
+
+func (m *Metrics) observeObjective(fullMethod, method string, err error, cost time.Duration) {
+	objective := FileDescriptor.Objective(fullMethod)
+	if objective == nil {
+		return
+	}
+
+	m.SLOTotal.WithLabelValues(method).Inc()
+	if objective.good(status.Code(err), cost) {
+		m.SLOGood.WithLabelValues(method).Inc()
+	}
+}
+
+// journalExemplar nil if it's longer than prometheus.ExemplarMaxRunes
+func journalExemplar(journalID string) prometheus.Labels {
+	if journalID == "" || utf8.RuneCountInString(JournalID)+utf8.RuneCountInString(journalID) > prometheus.ExemplarMaxRunes {
+		return nil
+	}
+
+	return prometheus.Labels{JournalID: journalID}
+}
+
+var _ prometheus.Collector = (*ErrorMessages)(nil)
+
+// ErrorMessages the top error messages counted, at most capacity of them kept by space-saving:
+// the least counted one evicted by a new one, which inherits it's count
+type ErrorMessages struct {
+	desc     *prometheus.Desc
+	capacity int
+
+	sync.Mutex
+	counts map[errorMessage]float64
+}
+
+type errorMessage struct {
+	method  string
+	code    string
+	message string
+}
+
+// NewErrorMessages create a collector keeps at most capacity error messages
+func NewErrorMessages(namespace, subsystem string, capacity int) *ErrorMessages {
+	return &ErrorMessages{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "error_messages_total"),
+			"top error message(s), approximately counted",
+			[]string{"method", "code", "message"}, nil,
+		),
+		capacity: capacity,
+		counts:   make(map[errorMessage]float64, capacity),
+	}
+}
+
+// Inc count the message, the invalid UTF-8 replaced by U+FFFD and truncated if it's too long
+func (e *ErrorMessages) Inc(method, code, message string) {
+	message = strings.ToValidUTF8(message, string(utf8.RuneError))
+	if utf8.RuneCountInString(message) > maxErrorMessageRunes {
+		message = string([]rune(message)[:maxErrorMessageRunes])
+	}
+	key := errorMessage{method: method, code: code, message: message}
+
+	e.Lock()
+	defer e.Unlock()
+
+	if _, ok := e.counts[key]; ok || len(e.counts) < e.capacity {
+		e.counts[key]++
+		return
+	}
+
+	var (
+		least      errorMessage
+		leastCount float64
+		found      bool
+	)
+	for k, count := range e.counts {
+		if !found || count < leastCount {
+			least, leastCount, found = k, count, true
+		}
+	}
+
+	if found {
+		delete(e.counts, least)
+		e.counts[key] = leastCount + 1
+	}
+}
+
+// Describe implements prometheus.Collector
+func (e *ErrorMessages) Describe(ch chan<- *prometheus.Desc) {
+	ch <- e.desc
+}
+
+// Collect implements prometheus.Collector
+func (e *ErrorMessages) Collect(ch chan<- prometheus.Metric) {
+	e.Lock()
+	defer e.Unlock()
+
+	for k, count := range e.counts {
+		metric, err := prometheus.NewConstMetric(e.desc, prometheus.CounterValue, count, k.method, k.code, k.message)
+		if err != nil {
+			metric = prometheus.NewInvalidMetric(e.desc, err) // reported by the gatherer instead of panic
+		}
+		ch <- metric
+	}
+}
+
+var _ prometheus.Collector = (*LatencyVec)(nil)
+
+// LatencyVec histograms labelled by method (and the extra labels), which buckets customized by options.latency_buckets
+type LatencyVec struct {
+	opts   prometheus.HistogramOpts
+	labels []string
+	desc   *prometheus.HistogramVec // describes every vec
+
+	sync.RWMutex
+	vecs map[string]*prometheus.HistogramVec // method : vec
+}
+
+// NewLatencyVec create histograms labelled by method and the extra labels, opts.Buckets are the default buckets
+func NewLatencyVec(opts prometheus.HistogramOpts, extraLabels ...string) *LatencyVec {
+	labels := append([]string{"method"}, extraLabels...)
+
+	return &LatencyVec{
+		opts:   opts,
+		labels: labels,
+		desc:   prometheus.NewHistogramVec(opts, labels),
+		vecs:   make(map[string]*prometheus.HistogramVec),
+	}
+}
+
+// WithLabelValues the buckets (nil the default) take effect when the method observed first time,
+// so the methods share an options.metrics_alias should share the buckets too
+func (l *LatencyVec) WithLabelValues(buckets []float64, method string, extraValues ...string) prometheus.Observer {
+	l.RLock()
+	vec, ok := l.vecs[method]
+	l.RUnlock()
+
+	if !ok {
+		l.Lock()
+		if vec, ok = l.vecs[method]; !ok {
+			opts := l.opts
+			if len(buckets) > 0 {
+				opts.Buckets = buckets
+			}
+
+			vec = prometheus.NewHistogramVec(opts, l.labels)
+			l.vecs[method] = vec
+		}
+		l.Unlock()
+	}
+
+	return vec.WithLabelValues(append([]string{method}, extraValues...)...)
+}
+
+// Describe implements prometheus.Collector
+func (l *LatencyVec) Describe(ch chan<- *prometheus.Desc) {
+	l.desc.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (l *LatencyVec) Collect(ch chan<- prometheus.Metric) {
+	l.RLock()
+	defer l.RUnlock()
+
+	for _, vec := range l.vecs {
+		vec.Collect(ch)
+	}
+}
+
+var _ prometheus.Collector = (*Objectives)(nil)
+
+// Objectives the options.slo of methods as gauges, so that alerts can be generated from them;
+// only the methods registered on the server(s) added reported
+type Objectives struct {
+	latency      *prometheus.Desc
+	availability *prometheus.Desc
+
+	sync.RWMutex
+	servers map[*grpc.Server]struct{}
+}
+
+// NewObjectives create a collector of options.slo
+func NewObjectives(namespace, subsystem string) *Objectives {
+	return &Objectives{
+		latency: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "slo_objective_latency_seconds"),
+			"the latency objective of method",
+			[]string{"method"}, nil,
+		),
+		availability: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "slo_objective_availability"),
+			"the availability objective of method",
+			[]string{"method"}, nil,
+		),
+		servers: make(map[*grpc.Server]struct{}),
+	}
+}
+
+// Add report the objectives of the methods registered on server
+func (o *Objectives) Add(server *grpc.Server) {
+	o.Lock()
+	defer o.Unlock()
+
+	o.servers[server] = struct{}{}
+}
+
+// Remove stop reporting the objectives of server, e.g. it's stopped
+func (o *Objectives) Remove(server *grpc.Server) {
+	o.Lock()
+	defer o.Unlock()
+
+	delete(o.servers, server)
+}
+
+// registered the methods registered on the server(s) added, FullMethod : true
+func (o *Objectives) registered() map[string]bool {
+	o.RLock()
+	defer o.RUnlock()
+
+	registered := make(map[string]bool)
+	for server := range o.servers {
+		for service, info := range server.GetServiceInfo() {
+			for _, method := range info.Methods {
+				registered["/"+service+"/"+method.Name] = true
+			}
+		}
+	}
+	return registered
+}
+
+// Describe implements prometheus.Collector
+func (o *Objectives) Describe(ch chan<- *prometheus.Desc) {
+	ch <- o.latency
+	ch <- o.availability
+}
+
+// Collect implements prometheus.Collector
+func (o *Objectives) Collect(ch chan<- prometheus.Metric) {
+	objectives := FileDescriptor.Objectives()
+	registered := o.registered()
+
+	fullMethods := make([]string, 0, len(objectives))
+	for fullMethod := range objectives {
+		if registered[fullMethod] {
+			fullMethods = append(fullMethods, fullMethod)
+		}
+	}
+	sort.Strings(fullMethods)
+
+	collected := make(map[string]bool, len(fullMethods))
+	for _, fullMethod := range fullMethods {
+		method := metricsMethod(fullMethod)
+		if collected[method] { // the first one of methods share an options.metrics_alias
+			continue
+		}
+		collected[method] = true
+
+		objective := objectives[fullMethod]
+		ch <- prometheus.MustNewConstMetric(o.latency, prometheus.GaugeValue, objective.latency.Seconds(), method)
+		ch <- prometheus.MustNewConstMetric(o.availability, prometheus.GaugeValue, objective.availability, method)
+	}
+}
+
+// register returns the existing one if already registered
+func register(registerer prometheus.Registerer, collector prometheus.Collector) (prometheus.Collector, error) {
+	if err := registerer.Register(collector); err != nil {
+		if registered, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return registered.ExistingCollector, nil
+		}
+		return nil, errors.Wrap(err, "register metrics err")
+	}
+
+	return collector, nil
+}
+
+func registerHistogram(registerer prometheus.Registerer, histogram *prometheus.HistogramVec) (*prometheus.HistogramVec, error) {
+	collector, err := register(registerer, histogram)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, ok := collector.(*prometheus.HistogramVec)
+	if !ok {
+		return nil, errors.Errorf("metrics registered as %T, not histogram", collector)
+	}
+	return existing, nil
+}
+
+func registerGauge(registerer prometheus.Registerer, gauge *prometheus.GaugeVec) (*prometheus.GaugeVec, error) {
+	collector, err := register(registerer, gauge)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, ok := collector.(*prometheus.GaugeVec)
+	if !ok {
+		return nil, errors.Errorf("metrics registered as %T, not gauge", collector)
+	}
+	return existing, nil
+}
+
+func registerCounter(registerer prometheus.Registerer, counter *prometheus.CounterVec) (*prometheus.CounterVec, error) {
+	collector, err := register(registerer, counter)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, ok := collector.(*prometheus.CounterVec)
+	if !ok {
+		return nil, errors.Errorf("metrics registered as %T, not counter", collector)
+	}
+	return existing, nil
+}
+
+func registerErrorMessages(registerer prometheus.Registerer, messages *ErrorMessages) (*ErrorMessages, error) {
+	collector, err := register(registerer, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, ok := collector.(*ErrorMessages)
+	if !ok {
+		return nil, errors.Errorf("metrics registered as %T, not error messages", collector)
+	}
+	return existing, nil
+}
+
+func registerLatency(registerer prometheus.Registerer, latency *LatencyVec) (*LatencyVec, error) {
+	collector, err := register(registerer, latency)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, ok := collector.(*LatencyVec)
+	if !ok {
+		return nil, errors.Errorf("metrics registered as %T, not latency", collector)
+	}
+	return existing, nil
+}
+
+func registerObjectives(registerer prometheus.Registerer, objectives *Objectives) (*Objectives, error) {
+	collector, err := register(registerer, objectives)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, ok := collector.(*Objectives)
+	if !ok {
+		return nil, errors.Errorf("metrics registered as %T, not objectives", collector)
+	}
+	return existing, nil
+}
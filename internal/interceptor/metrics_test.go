@@ -0,0 +1,223 @@
+package interceptor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// collectErrorMessages message : count, and the type of them
+func collectErrorMessages(t *testing.T, collector prometheus.Collector) (map[string]float64, []dto.MetricType) {
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(collector); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]float64)
+	var types []dto.MetricType
+	for _, family := range families {
+		types = append(types, family.GetType())
+		for _, metric := range family.Metric {
+			for _, label := range metric.Label {
+				if label.GetName() == "message" {
+					counts[label.GetValue()] = metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	return counts, types
+}
+
+func TestErrorMessages(t *testing.T) {
+	cases := []struct {
+		name     string
+		capacity int
+		messages []string
+		want     map[string]float64
+	}{
+		{
+			name:     "within capacity",
+			capacity: 3,
+			messages: []string{"a", "b", "a"},
+			want:     map[string]float64{"a": 2, "b": 1},
+		},
+		{
+			name:     "least evicted and inherited",
+			capacity: 2,
+			messages: []string{"a", "a", "b", "c"},
+			want:     map[string]float64{"a": 2, "c": 2},
+		},
+		{
+			name:     "invalid utf-8",
+			capacity: 1,
+			messages: []string{"bad\xff"},
+			want:     map[string]float64{"bad�": 1},
+		},
+		{
+			name:     "truncated",
+			capacity: 1,
+			messages: []string{strings.Repeat("x", maxErrorMessageRunes+1)},
+			want:     map[string]float64{strings.Repeat("x", maxErrorMessageRunes): 1},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			collector := NewErrorMessages("test", "vv", c.capacity)
+			for _, message := range c.messages {
+				collector.Inc("/x.Y/Z", "Internal", message)
+			}
+
+			counts, types := collectErrorMessages(t, collector)
+			if fmt.Sprint(counts) != fmt.Sprint(c.want) {
+				t.Errorf("got %v, want %v", counts, c.want)
+			}
+			for _, typ := range types {
+				if typ != dto.MetricType_COUNTER {
+					t.Errorf("type %v, want counter", typ)
+				}
+			}
+		})
+	}
+}
+
+func TestObserveErrorMessage(t *testing.T) {
+	metrics, err := NewMetrics(nil, "test", "vv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, journalID := range []string{"id1", "id2", ""} {
+		err := status.Error(codes.Internal, fmt.Sprintf("got panic => journal_id: %s, error: boom", journalID))
+		metrics.ObserveError("/x.Y/Z", err, journalID, time.Millisecond)
+	}
+
+	counts, _ := collectErrorMessages(t, metrics.ErrorMessages)
+
+	var messages []string
+	for message, count := range counts {
+		messages = append(messages, fmt.Sprintf("%s=%v", message, count))
+	}
+	sort.Strings(messages)
+
+	want := []string{"got panic => journal_id: , error: boom=1", "got panic => journal_id: -, error: boom=2"}
+	if strings.Join(messages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", messages, want)
+	}
+}
+
+func TestStreamMetricsNames(t *testing.T) {
+	metrics, err := NewMetrics(nil, "test", "vv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		collector prometheus.Collector
+		observe   func()
+		name      string
+	}{
+		{collector: metrics.StreamReceived, observe: func() { metrics.StreamReceived.WithLabelValues("x").Inc() }, name: "test_vv_stream_received_total"},
+		{collector: metrics.StreamSent, observe: func() { metrics.StreamSent.WithLabelValues("x").Inc() }, name: "test_vv_stream_sent_total"},
+		{collector: metrics.StreamDuration, observe: func() { metrics.StreamDuration.WithLabelValues("x").Observe(1) }, name: "test_vv_stream_duration_seconds"},
+	}
+
+	for _, c := range cases {
+		registry := prometheus.NewPedanticRegistry()
+		if err := registry.Register(c.collector); err != nil {
+			t.Fatal(err)
+		}
+		c.observe()
+
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(families) != 1 || families[0].GetName() != c.name {
+			t.Errorf("got %v, want %s", families, c.name)
+		}
+	}
+}
+
+// newObjectiveServer a server with service, which methods have options.slo
+func newObjectiveServer(service string, methods ...string) *grpc.Server {
+	desc := &grpc.ServiceDesc{ServiceName: service, HandlerType: (*interface{})(nil)}
+
+	FileDescriptor.Lock()
+	for _, method := range methods {
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{MethodName: method})
+		FileDescriptor.objectives["/"+service+"/"+method] = &objective{latency: time.Second, availability: 0.99}
+	}
+	FileDescriptor.Unlock()
+
+	server := grpc.NewServer()
+	server.RegisterService(desc, struct{}{})
+	return server
+}
+
+func TestObjectives(t *testing.T) {
+	a := newObjectiveServer("vv.test.A", "X", "Y")
+	b := newObjectiveServer("vv.test.B", "Z")
+
+	cases := []struct {
+		name    string
+		servers []*grpc.Server
+		removed []*grpc.Server
+		methods []string
+	}{
+		{name: "none"},
+		{name: "own server only", servers: []*grpc.Server{a}, methods: []string{"/vv.test.A/X", "/vv.test.A/Y"}},
+		{name: "shared by servers", servers: []*grpc.Server{a, b}, methods: []string{"/vv.test.A/X", "/vv.test.A/Y", "/vv.test.B/Z"}},
+		{name: "removed", servers: []*grpc.Server{a, b}, removed: []*grpc.Server{a}, methods: []string{"/vv.test.B/Z"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objectives := NewObjectives("test", "vv")
+			for _, server := range c.servers {
+				objectives.Add(server)
+			}
+			for _, server := range c.removed {
+				objectives.Remove(server)
+			}
+
+			registry := prometheus.NewPedanticRegistry()
+			if err := registry.Register(objectives); err != nil {
+				t.Fatal(err)
+			}
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var methods []string
+			for _, family := range families {
+				if family.GetName() != "test_vv_slo_objective_availability" {
+					continue
+				}
+				for _, metric := range family.Metric {
+					methods = append(methods, metric.Label[0].GetValue())
+				}
+			}
+			sort.Strings(methods)
+
+			if strings.Join(methods, ",") != strings.Join(c.methods, ",") {
+				t.Errorf("got %v, want %v", methods, c.methods)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package interceptor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/bluekaki/vv/options"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// RedactedValue the value of redacted metadata in journal
+const RedactedValue = "******"
+
+// TruncatedPrefix the prefix of oversized metadata in journal, followed by the size and sha256
+const TruncatedPrefix = "[truncated]"
+
+// Redactor redact the sensitive fields (options.sensitive) of message before it journaled
+var Redactor = &redactor{
+	rules: make(map[protoreflect.FullName]*redactRules),
+}
+
+type redactor struct {
+	sync.RWMutex
+	rules map[protoreflect.FullName]*redactRules // message FullName : rules
+}
+
+type redactRules struct {
+	fields    []*redactField
+	sensitive bool // has sensitive field(s), directly or nested
+}
+
+type redactField struct {
+	descriptor protoreflect.FieldDescriptor
+	sensitive  options.Sensitive
+	nested     *redactRules // for message, the elements of list and the values of map
+}
+
+// Redact returns a redacted clone of message, or message itself if nothing sensitive;
+// the HASH fields are HMAC-SHA256 keyed by hashKey, so that they can't be brute forced without it.
+func (r *redactor) Redact(message proto.Message, hashKey []byte) proto.Message {
+	rules := r.load(message.ProtoReflect().Descriptor())
+	if !rules.sensitive {
+		return message
+	}
+
+	clone := proto.Clone(message)
+	rules.apply(clone.ProtoReflect(), false, hashKey)
+	return clone
+}
+
+// Strip returns a clone of message without the sensitive fields, or message itself if nothing sensitive,
+// e.g. compare the messages regardless of how the sensitive fields redacted
+func (r *redactor) Strip(message proto.Message) proto.Message {
+	rules := r.load(message.ProtoReflect().Descriptor())
+	if !rules.sensitive {
+		return message
+	}
+
+	clone := proto.Clone(message)
+	rules.apply(clone.ProtoReflect(), true, nil)
+	return clone
+}
+
+// Sensitive the message has sensitive field(s), directly or nested
+func (r *redactor) Sensitive(descriptor protoreflect.MessageDescriptor) bool {
+	return r.load(descriptor).sensitive
+}
+
+func (r *redactor) load(descriptor protoreflect.MessageDescriptor) *redactRules {
+	r.RLock()
+	rules, ok := r.rules[descriptor.FullName()]
+	r.RUnlock()
+	if ok {
+		return rules
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	rules = r.compile(descriptor)
+
+	// propagate sensitive through nested (maybe recursive) messages until stable
+	for changed := true; changed; {
+		changed = false
+		for _, candidate := range r.rules {
+			if candidate.sensitive {
+				continue
+			}
+
+			for _, field := range candidate.fields {
+				if field.sensitive != options.Sensitive_NOT_SENSITIVE || (field.nested != nil && field.nested.sensitive) {
+					candidate.sensitive = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	return rules
+}
+
+func (r *redactor) compile(descriptor protoreflect.MessageDescriptor) *redactRules {
+	if rules, ok := r.rules[descriptor.FullName()]; ok {
+		return rules
+	}
+
+	rules := new(redactRules)
+	r.rules[descriptor.FullName()] = rules // placeholder for recursive message
+
+	fields := descriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+
+		rule := &redactField{
+			descriptor: field,
+			sensitive:  proto.GetExtension(field.Options(), options.E_Sensitive).(options.Sensitive),
+		}
+
+		valueField := field
+		if field.IsMap() {
+			valueField = field.MapValue()
+		}
+		if valueField.Message() != nil {
+			rule.nested = r.compile(valueField.Message())
+		}
+
+		if rule.sensitive != options.Sensitive_NOT_SENSITIVE || rule.nested != nil {
+			rules.fields = append(rules.fields, rule)
+		}
+	}
+
+	return rules
+}
+
+// apply redact the sensitive fields, or clear them if strip
+func (r *redactRules) apply(message protoreflect.Message, strip bool, hashKey []byte) {
+	for _, field := range r.fields {
+		descriptor := field.descriptor
+		if !message.Has(descriptor) {
+			continue
+		}
+
+		switch field.sensitive {
+		case options.Sensitive_DROP:
+			message.Clear(descriptor)
+			continue
+
+		case options.Sensitive_MASK, options.Sensitive_HASH:
+			if strip {
+				message.Clear(descriptor)
+			} else {
+				redactScalar(message, descriptor, field.sensitive, hashKey)
+			}
+			continue
+		}
+
+		if !field.nested.sensitive {
+			continue
+		}
+
+		switch {
+		case descriptor.IsList():
+			list := message.Mutable(descriptor).List()
+			for i := 0; i < list.Len(); i++ {
+				field.nested.apply(list.Get(i).Message(), strip, hashKey)
+			}
+
+		case descriptor.IsMap():
+			message.Mutable(descriptor).Map().Range(func(_ protoreflect.MapKey, value protoreflect.Value) bool {
+				field.nested.apply(value.Message(), strip, hashKey)
+				return true
+			})
+
+		default:
+			field.nested.apply(message.Mutable(descriptor).Message(), strip, hashKey)
+		}
+	}
+}
+
+// redactScalar mask or hash string & bytes, others cleared
+func redactScalar(message protoreflect.Message, descriptor protoreflect.FieldDescriptor, sensitive options.Sensitive, hashKey []byte) {
+	valueField := descriptor
+	if descriptor.IsMap() {
+		valueField = descriptor.MapValue()
+	}
+
+	if valueField.Kind() != protoreflect.StringKind && !(valueField.Kind() == protoreflect.BytesKind && sensitive == options.Sensitive_HASH) {
+		message.Clear(descriptor)
+		return
+	}
+
+	switch {
+	case descriptor.IsList():
+		list := message.Mutable(descriptor).List()
+		for i := 0; i < list.Len(); i++ {
+			list.Set(i, redactValue(list.Get(i), sensitive, hashKey))
+		}
+
+	case descriptor.IsMap():
+		mp := message.Mutable(descriptor).Map()
+		mp.Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+			mp.Set(key, redactValue(value, sensitive, hashKey))
+			return true
+		})
+
+	default:
+		message.Set(descriptor, redactValue(message.Get(descriptor), sensitive, hashKey))
+	}
+}
+
+func redactValue(value protoreflect.Value, sensitive options.Sensitive, hashKey []byte) protoreflect.Value {
+	switch raw := value.Interface().(type) {
+	case string:
+		if sensitive == options.Sensitive_HASH {
+			return protoreflect.ValueOfString(hex.EncodeToString(hashValue([]byte(raw), hashKey)))
+		}
+		return protoreflect.ValueOfString(maskString(raw))
+
+	case []byte:
+		return protoreflect.ValueOfBytes(hashValue(raw, hashKey))
+	}
+
+	return value
+}
+
+// hashValue HMAC-SHA256 of raw keyed by hashKey
+func hashValue(raw, hashKey []byte) []byte {
+	mac := hmac.New(sha256.New, hashKey)
+	mac.Write(raw)
+	return mac.Sum(nil)
+}
+
+// maskString keep at most the last 4 characters (a third of the short one), the rest replaced by '*'
+func maskString(raw string) string {
+	runes := []rune(raw)
+
+	keep := len(runes) / 3
+	if keep > 4 {
+		keep = 4
+	}
+
+	return strings.Repeat("*", len(runes)-keep) + string(runes[len(runes)-keep:])
+}
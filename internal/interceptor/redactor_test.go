@@ -0,0 +1,223 @@
+package interceptor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"testing"
+
+	"github.com/bluekaki/vv/options"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var (
+	secretOnce       sync.Once
+	secretDescriptor protoreflect.MessageDescriptor
+)
+
+// newSecretDescriptor message Secret { plain, masked(MASK), hashed(HASH), blob(HASH), pin(MASK), dropped(DROP), tags(MASK), child },
+// built once as Redactor caches the rules by the full name
+func newSecretDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	secretOnce.Do(func() {
+		secretDescriptor = buildSecretDescriptor(t)
+	})
+	return secretDescriptor
+}
+
+func buildSecretDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, sensitive options.Sensitive) *descriptorpb.FieldDescriptorProto {
+		fieldOptions := new(descriptorpb.FieldOptions)
+		if sensitive != options.Sensitive_NOT_SENSITIVE {
+			proto.SetExtension(fieldOptions, options.E_Sensitive, sensitive)
+		}
+
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     kind.Enum(),
+			Options:  fieldOptions,
+		}
+	}
+
+	tags := field("tags", 7, descriptorpb.FieldDescriptorProto_TYPE_STRING, options.Sensitive_MASK)
+	tags.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+
+	child := field("child", 8, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, options.Sensitive_NOT_SENSITIVE)
+	child.TypeName = proto.String(".vv.test.redactor.Secret")
+
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("vv/test/redactor.proto"),
+		Package: proto.String("vv.test.redactor"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Secret"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("plain", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, options.Sensitive_NOT_SENSITIVE),
+				field("masked", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, options.Sensitive_MASK),
+				field("hashed", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, options.Sensitive_HASH),
+				field("blob", 4, descriptorpb.FieldDescriptorProto_TYPE_BYTES, options.Sensitive_HASH),
+				field("pin", 5, descriptorpb.FieldDescriptorProto_TYPE_INT32, options.Sensitive_MASK),
+				field("dropped", 6, descriptorpb.FieldDescriptorProto_TYPE_STRING, options.Sensitive_DROP),
+				tags,
+				child,
+			},
+		}},
+	}, new(protoregistry.Files))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return file.Messages().Get(0)
+}
+
+func TestRedactor(t *testing.T) {
+	descriptor := newSecretDescriptor(t)
+	key := []byte("key")
+
+	hmacHex := func(raw string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(raw))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	hmacRaw := func(raw string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(raw))
+		return string(mac.Sum(nil))
+	}
+
+	var newSecret func(depth int) *dynamicpb.Message
+	newSecret = func(depth int) *dynamicpb.Message {
+		message := dynamicpb.NewMessage(descriptor)
+		set := func(name string, value protoreflect.Value) {
+			message.Set(descriptor.Fields().ByName(protoreflect.Name(name)), value)
+		}
+
+		set("plain", protoreflect.ValueOfString("plain"))
+		set("masked", protoreflect.ValueOfString("13800001111"))
+		set("hashed", protoreflect.ValueOfString("id-card"))
+		set("blob", protoreflect.ValueOfBytes([]byte("blob")))
+		set("pin", protoreflect.ValueOfInt32(1234))
+		set("dropped", protoreflect.ValueOfString("password"))
+
+		tags := message.Mutable(descriptor.Fields().ByName("tags")).List()
+		tags.Append(protoreflect.ValueOfString("abc"))
+		tags.Append(protoreflect.ValueOfString("abcdefghijkl"))
+
+		if depth > 0 {
+			child := message.Mutable(descriptor.Fields().ByName("child")).Message()
+			proto.Merge(child.Interface(), newSecret(depth-1))
+		}
+		return message
+	}
+
+	get := func(message protoreflect.Message, path ...string) interface{} {
+		for _, name := range path[:len(path)-1] {
+			message = message.Get(descriptor.Fields().ByName(protoreflect.Name(name))).Message()
+		}
+
+		field := descriptor.Fields().ByName(protoreflect.Name(path[len(path)-1]))
+		if !message.Has(field) {
+			return nil
+		}
+
+		value := message.Get(field)
+		if field.IsList() {
+			var values []string
+			for i := 0; i < value.List().Len(); i++ {
+				values = append(values, value.List().Get(i).String())
+			}
+			return values
+		}
+		if field.Kind() == protoreflect.BytesKind {
+			return string(value.Bytes())
+		}
+		return value.Interface()
+	}
+
+	redacted := Redactor.Redact(newSecret(1), key).ProtoReflect()
+	stripped := Redactor.Strip(newSecret(1)).ProtoReflect()
+
+	cases := []struct {
+		path     []string
+		redacted interface{}
+		stripped interface{}
+	}{
+		{path: []string{"plain"}, redacted: "plain", stripped: "plain"},
+		{path: []string{"masked"}, redacted: "********111"},
+		{path: []string{"hashed"}, redacted: hmacHex("id-card")},
+		{path: []string{"blob"}, redacted: hmacRaw("blob")},
+		{path: []string{"pin"}},
+		{path: []string{"dropped"}},
+		{path: []string{"tags"}, redacted: []string{"**c", "********ijkl"}},
+		{path: []string{"child", "plain"}, redacted: "plain", stripped: "plain"},
+		{path: []string{"child", "masked"}, redacted: "********111"},
+		{path: []string{"child", "hashed"}, redacted: hmacHex("id-card")},
+		{path: []string{"child", "dropped"}},
+	}
+
+	for _, c := range cases {
+		if got := get(redacted, c.path...); !equalRedacted(got, c.redacted) {
+			t.Errorf("redacted %v = %v, want %v", c.path, got, c.redacted)
+		}
+		if got := get(stripped, c.path...); !equalRedacted(got, c.stripped) {
+			t.Errorf("stripped %v = %v, want %v", c.path, got, c.stripped)
+		}
+	}
+
+	if !Redactor.Sensitive(descriptor) {
+		t.Error("Secret should be sensitive")
+	}
+
+	other := Redactor.Redact(newSecret(0), []byte("other key")).ProtoReflect()
+	if get(other, "hashed") == get(redacted, "hashed") {
+		t.Error("hash should depend on the key")
+	}
+}
+
+func equalRedacted(got, want interface{}) bool {
+	gotList, ok := got.([]string)
+	if !ok {
+		return got == want
+	}
+
+	wantList, _ := want.([]string)
+	if len(gotList) != len(wantList) {
+		return false
+	}
+	for i := range gotList {
+		if gotList[i] != wantList[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMaskString(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "", want: ""},
+		{raw: "ab", want: "**"},
+		{raw: "abc", want: "**c"},
+		{raw: "abcdef", want: "****ef"},
+		{raw: "13800001111", want: "********111"},
+		{raw: "6222020200112233445", want: "***************3445"},
+		{raw: "张三丰", want: "**丰"},
+	}
+
+	for _, c := range cases {
+		if got := maskString(c.raw); got != c.want {
+			t.Errorf("maskString(%q) = %q, want %q", c.raw, got, c.want)
+		}
+	}
+}
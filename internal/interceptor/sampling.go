@@ -0,0 +1,82 @@
+package interceptor
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/bluekaki/vv/internal/protos/gen"
+	"github.com/bluekaki/vv/options"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// journalSampling compiled from options.journal_sampling & options.journal_slow_threshold
+type journalSampling struct {
+	rate          float64
+	slowThreshold time.Duration
+}
+
+func parseJournalSampling(methodOptions protoreflect.ProtoMessage) (*journalSampling, error) {
+	if !proto.HasExtension(methodOptions, options.E_JournalSampling) {
+		if proto.HasExtension(methodOptions, options.E_JournalSlowThreshold) {
+			return nil, errors.New("options.journal_slow_threshold works with options.journal_sampling")
+		}
+		return nil, nil
+	}
+
+	sampling := &journalSampling{
+		rate: proto.GetExtension(methodOptions, options.E_JournalSampling).(float64),
+	}
+	if sampling.rate < 0 || sampling.rate > 1 {
+		return nil, errors.Errorf("options.journal_sampling %v out of [0, 1]", sampling.rate)
+	}
+
+	if raw := proto.GetExtension(methodOptions, options.E_JournalSlowThreshold).(string); raw != "" {
+		threshold, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, errors.Wrap(err, "options.journal_slow_threshold illegal")
+		}
+		if threshold <= 0 {
+			return nil, errors.Errorf("options.journal_slow_threshold %s must be positive", raw)
+		}
+
+		sampling.slowThreshold = threshold
+	}
+
+	return sampling, nil
+}
+
+// sampleJournal the sampling is nil if the method journal every call
+func sampleJournal(sampling *journalSampling, err error, cost time.Duration) (*pb.Sampling, bool) {
+	if sampling == nil {
+		return nil, true
+	}
+
+	return sampling.sample(err, cost)
+}
+
+// sample errors and slow calls always journaled, the others journaled at rate
+func (j *journalSampling) sample(err error, cost time.Duration) (*pb.Sampling, bool) {
+	sampling := &pb.Sampling{
+		Rate:                 j.rate,
+		SlowThresholdSeconds: j.slowThreshold.Seconds(),
+	}
+
+	switch {
+	case err != nil:
+		sampling.Reason = pb.Sampling_ERROR
+
+	case j.slowThreshold > 0 && cost >= j.slowThreshold:
+		sampling.Reason = pb.Sampling_SLOW
+
+	case rand.Float64() < j.rate:
+		sampling.Reason = pb.Sampling_SAMPLED
+
+	default:
+		return nil, false
+	}
+
+	return sampling, true
+}
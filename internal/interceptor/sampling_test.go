@@ -0,0 +1,89 @@
+package interceptor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bluekaki/vv/internal/protos/gen"
+	"github.com/bluekaki/vv/options"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestParseJournalSampling(t *testing.T) {
+	cases := []struct {
+		name          string
+		rate          *float64
+		slowThreshold string
+		want          *journalSampling
+		err           bool
+	}{
+		{name: "absent"},
+		{name: "rate", rate: proto.Float64(0.1), want: &journalSampling{rate: 0.1}},
+		{name: "rate and slow", rate: proto.Float64(0), slowThreshold: "200ms", want: &journalSampling{slowThreshold: time.Millisecond * 200}},
+		{name: "rate out of range", rate: proto.Float64(1.5), err: true},
+		{name: "negative rate", rate: proto.Float64(-0.1), err: true},
+		{name: "slow without rate", slowThreshold: "1s", err: true},
+		{name: "slow illegal", rate: proto.Float64(1), slowThreshold: "fast", err: true},
+		{name: "slow not positive", rate: proto.Float64(1), slowThreshold: "-1s", err: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			methodOptions := new(descriptorpb.MethodOptions)
+			if c.rate != nil {
+				proto.SetExtension(methodOptions, options.E_JournalSampling, *c.rate)
+			}
+			if c.slowThreshold != "" {
+				proto.SetExtension(methodOptions, options.E_JournalSlowThreshold, c.slowThreshold)
+			}
+
+			sampling, err := parseJournalSampling(methodOptions)
+			if (err != nil) != c.err {
+				t.Fatalf("err %v", err)
+			}
+
+			if (sampling == nil) != (c.want == nil) || (sampling != nil && *sampling != *c.want) {
+				t.Errorf("got %+v, want %+v", sampling, c.want)
+			}
+		})
+	}
+}
+
+func TestSampleJournal(t *testing.T) {
+	cases := []struct {
+		name     string
+		sampling *journalSampling
+		err      error
+		cost     time.Duration
+		reason   pb.Sampling_Reason
+		sampled  bool
+	}{
+		{name: "journal every call", sampled: true},
+		{name: "error", sampling: &journalSampling{}, err: errors.New("x"), reason: pb.Sampling_ERROR, sampled: true},
+		{name: "slow", sampling: &journalSampling{slowThreshold: time.Second}, cost: time.Second, reason: pb.Sampling_SLOW, sampled: true},
+		{name: "fast", sampling: &journalSampling{slowThreshold: time.Second}, cost: time.Millisecond},
+		{name: "rate 1", sampling: &journalSampling{rate: 1}, reason: pb.Sampling_SAMPLED, sampled: true},
+		{name: "rate 0", sampling: &journalSampling{rate: 0}},
+	}
+
+	for _, c := range cases {
+		sampling, sampled := sampleJournal(c.sampling, c.err, c.cost)
+		if sampled != c.sampled {
+			t.Errorf("%s: sampled %v, want %v", c.name, sampled, c.sampled)
+		}
+
+		if c.sampling == nil || !sampled {
+			if sampling != nil {
+				t.Errorf("%s: sampling %v, want nil", c.name, sampling)
+			}
+			continue
+		}
+
+		if sampling.Reason != c.reason || sampling.Rate != c.sampling.rate || sampling.SlowThresholdSeconds != c.sampling.slowThreshold.Seconds() {
+			t.Errorf("%s: sampling %v", c.name, sampling)
+		}
+	}
+}
@@ -2,21 +2,19 @@ package interceptor
 
 import (
 	"context"
-	"crypto/rand"
 	"fmt"
-	"io"
 	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bluekaki/vv/internal/protos/gen"
+	"github.com/bluekaki/vv/internal/tracing"
 	"github.com/bluekaki/vv/options"
 
 	protoV1 "github.com/golang/protobuf/proto"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
-	"github.com/koketama/minami58"
 	"github.com/koketama/pbutil"
-	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"google.golang.org/genproto/googleapis/api/annotations"
 	"google.golang.org/grpc"
@@ -24,7 +22,7 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
@@ -46,20 +44,27 @@ const (
 	XForwardedFor = "x-forwarded-for"
 	// XForwardedHost forwarded host
 	XForwardedHost = "x-forwarded-host"
+	// XJournalID the journal id from upstream of gateway
+	XJournalID = "x-journal-id"
+	// RedactedKeys the metadata keys which caller (e.g. gateway) asks to be redacted in journal, comma separated
+	RedactedKeys = "vv-redacted"
 )
 
 // SessionUserinfo mark userinfo in context
 type SessionUserinfo struct{}
 
-var toLoggedMetadata = map[string]bool{
-	Authorization:      true,
-	ProxyAuthorization: true,
-	Date:               true,
-	Method:             true,
-	URI:                true,
-	Body:               true,
-	XForwardedFor:      true,
-	XForwardedHost:     true,
+// DefaultLoggedMetadata the metadata keys journaled by default
+func DefaultLoggedMetadata() []string {
+	return []string{
+		Authorization,
+		ProxyAuthorization,
+		Date,
+		Method,
+		URI,
+		Body,
+		XForwardedFor,
+		XForwardedHost,
+	}
 }
 
 var _ Payload = (*restPayload)(nil)
@@ -155,162 +160,107 @@ func (g *grpcPayload) Body() string {
 
 func (g *grpcPayload) t() {}
 
-// NewServerInterceptor create a server interceptor
-func NewServerInterceptor(logger *zap.Logger, enablePrometheus bool) *ServerInterceptor {
-	return &ServerInterceptor{
-		logger:           logger,
-		enablePrometheus: enablePrometheus,
+// NewServerInterceptor create a server interceptor, metrics nil means prometheus disabled
+func NewServerInterceptor(logger *zap.Logger, journal *JournalConfig, exporter tracing.Exporter, metrics *Metrics, requireStreamMessageSign bool) *ServerInterceptor {
+	interceptor := &ServerInterceptor{
+		logger:                   logger,
+		journal:                  newJournalBuilder(journal),
+		metrics:                  metrics,
+		requireStreamMessageSign: requireStreamMessageSign,
+	}
+
+	if exporter != nil {
+		interceptor.tracer = tracing.NewTracer(exporter, func(err error) {
+			logger.Error("export span err", zap.Error(err))
+		})
 	}
+
+	return interceptor
 }
 
 // ServerInterceptor the server's interceptor
 type ServerInterceptor struct {
-	logger           *zap.Logger
-	enablePrometheus bool
-}
-
-func (s *ServerInterceptor) journalID() string {
-	nonce := make([]byte, 16)
-	io.ReadFull(rand.Reader, nonce)
-
-	return string(minami58.Encode(nonce))
+	logger  *zap.Logger
+	journal *journalBuilder
+	tracer  *tracing.Tracer // nil when tracing disabled
+	metrics *Metrics        // nil when prometheus disabled
+	// requireStreamMessageSign reject the stream of method with options.proxy_authorization unless every message signed
+	requireStreamMessageSign bool
 }
 
-// UnaryInterceptor a interceptor for server unary operations
-func (s *ServerInterceptor) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
-	ts := time.Now()
-	journalID := s.journalID()
-
-	fullMethod := strings.Split(info.FullMethod, "/")
-	serviceName := fullMethod[1]
-	methodName := fullMethod[2]
-
-	doJournal := false
-	if proto.GetExtension(FileDescriptor.Options(info.FullMethod), options.E_Journal).(bool) {
-		doJournal = true
+// startSpan child of the remote span in metadata, nil when tracing disabled
+func (s *ServerInterceptor) startSpan(ctx context.Context, fullMethod, journalID string) (context.Context, *tracing.Span) {
+	if s.tracer == nil {
+		return ctx, nil
 	}
 
-	defer func() { // double recover for safety
-		if p := recover(); p != nil {
-			s, _ := status.New(codes.Internal, fmt.Sprintf("got double panic => journal_id: %s, error: %+v", journalID, p)).WithDetails(&pb.Stack{Info: string(debug.Stack())})
-			err = s.Err()
-		}
-	}()
-
-	defer func() {
-		if p := recover(); p != nil {
-			s, _ := status.New(codes.Internal, fmt.Sprintf("got panic => journal_id: %s, error: %+v", journalID, p)).WithDetails(&pb.Stack{Info: string(debug.Stack())})
-			err = s.Err()
-		}
-
-		grpc.SetHeader(ctx, metadata.Pairs(runtime.MetadataHeaderPrefix+JournalID, journalID))
-
-		if doJournal {
-			journal := &pb.Journal{
-				Id: journalID,
-				Request: &pb.Request{
-					Restapi: ForwardedByGrpcGateway(ctx),
-					Method:  info.FullMethod,
-					Metadata: func() map[string]string {
-						meta, _ := metadata.FromIncomingContext(ctx)
-						mp := make(map[string]string)
-						for key, values := range meta {
-							if toLoggedMetadata[key] {
-								mp[key] = values[0]
-							}
-						}
-						return mp
-					}(),
-					Payload: func() *anypb.Any {
-						if req == nil {
-							return nil
-						}
-
-						any, _ := anypb.New(req.(proto.Message))
-						return any
-					}(),
-				},
-				Response: &pb.Response{
-					Code: codes.OK.String(),
-					Payload: func() *anypb.Any {
-						if resp == nil {
-							return nil
-						}
-
-						any, _ := anypb.New(resp.(proto.Message))
-						return any
-					}(),
-				},
-				Success: err == nil,
-			}
+	meta, _ := metadata.FromIncomingContext(ctx)
+	parent, _ := tracing.Extract(meta)
 
-			if err != nil {
-				s, _ := status.FromError(err)
-				journal.Response.Code = s.Code().String()
-				journal.Response.Message = s.Message()
+	ctx, span := startSpan(ctx, s.tracer, fullMethod, tracing.SpanKindServer, parent)
+	span.SetAttribute("vv.journal_id", journalID)
+	return ctx, span
+}
 
-				journal.Response.Details = make([]*anypb.Any, len(s.Details()))
-				for i, detail := range s.Details() {
-					journal.Response.Details[i], _ = anypb.New(detail.(proto.Message))
-				}
-			}
+// journalID honour the upstream's if trusted, otherwise mint a random one
+func (s *ServerInterceptor) journalID(ctx context.Context) string {
+	if s.journal.trustUpstreamID != nil {
+		meta, _ := metadata.FromIncomingContext(ctx)
+		if id := meta.Get(JournalID); len(id) > 0 && validJournalID(id[0]) && s.journal.trustUpstreamID(ctx) {
+			return id[0]
+		}
+	}
 
-			journal.CostSeconds = time.Since(ts).Seconds()
+	return NewJournalID()
+}
 
-			json, _ := pbutil.ProtoMessage2Map(journal)
-			if err == nil {
-				s.logger.Info("unary interceptor", zap.Any("journal", json))
-			} else {
-				s.logger.Error("unary interceptor", zap.Any("journal", json))
-			}
+func metricsMethod(fullMethod string) string {
+	method := fullMethod
+
+	if http := proto.GetExtension(FileDescriptor.Options(fullMethod), annotations.E_Http).(*annotations.HttpRule); http != nil {
+		if x, ok := http.GetPattern().(*annotations.HttpRule_Get); ok {
+			method = "get " + x.Get
+		} else if x, ok := http.GetPattern().(*annotations.HttpRule_Put); ok {
+			method = "put " + x.Put
+		} else if x, ok := http.GetPattern().(*annotations.HttpRule_Post); ok {
+			method = "post " + x.Post
+		} else if x, ok := http.GetPattern().(*annotations.HttpRule_Delete); ok {
+			method = "delete " + x.Delete
+		} else if x, ok := http.GetPattern().(*annotations.HttpRule_Patch); ok {
+			method = "patch " + x.Patch
 		}
+	}
 
-		if s.enablePrometheus {
-			method := info.FullMethod
-
-			if http := proto.GetExtension(FileDescriptor.Options(info.FullMethod), annotations.E_Http).(*annotations.HttpRule); http != nil {
-				if x, ok := http.GetPattern().(*annotations.HttpRule_Get); ok {
-					method = "get " + x.Get
-				} else if x, ok := http.GetPattern().(*annotations.HttpRule_Put); ok {
-					method = "put " + x.Put
-				} else if x, ok := http.GetPattern().(*annotations.HttpRule_Post); ok {
-					method = "post " + x.Post
-				} else if x, ok := http.GetPattern().(*annotations.HttpRule_Delete); ok {
-					method = "delete " + x.Delete
-				} else if x, ok := http.GetPattern().(*annotations.HttpRule_Patch); ok {
-					method = "patch " + x.Patch
-				}
-			}
-
-			if alias := proto.GetExtension(FileDescriptor.Options(info.FullMethod), options.E_MetricsAlias).(string); alias != "" {
-				method = alias
-			}
+	if alias := proto.GetExtension(FileDescriptor.Options(fullMethod), options.E_MetricsAlias).(string); alias != "" {
+		method = alias
+	}
 
-			if err == nil {
-				MetricsRequestCost.WithLabelValues(method).Observe(time.Since(ts).Seconds())
-			} else {
-				MetricsError.WithLabelValues(method, status.Code(err).String(), err.Error(), journalID).Observe(time.Since(ts).Seconds())
-			}
-		}
-	}()
+	return method
+}
 
-	meta, _ := metadata.FromIncomingContext(ctx)
-	meta.Set(JournalID, journalID)
-	ctx = metadata.NewOutgoingContext(ctx, meta)
+func (s *ServerInterceptor) observe(fullMethod, journalID string, err error, ts time.Time) {
+	if err == nil {
+		s.metrics.ObserveSuccess(fullMethod, time.Since(ts))
+	} else {
+		s.metrics.ObserveError(fullMethod, err, journalID, time.Since(ts))
+	}
+}
 
+// authorize validate authorization & proxy_authorization by method options, returns ctx with userinfo
+func (s *ServerInterceptor) authorize(ctx context.Context, meta metadata.MD, fullMethod, journalID string, body func() string) (context.Context, error) {
 	var (
 		authorizationValidator      userinfoHandler
 		proxyAuthorizationValidator signatureHandler
 	)
-	if option := proto.GetExtension(FileDescriptor.Options(info.FullMethod), options.E_Authorization).(*options.Handler); option != nil {
+	if option := proto.GetExtension(FileDescriptor.Options(fullMethod), options.E_Authorization).(*options.Handler); option != nil {
 		authorizationValidator = Validator.AuthorizationValidator(option.Name)
 	}
-	if option := proto.GetExtension(FileDescriptor.Options(info.FullMethod), options.E_ProxyAuthorization).(*options.Handler); option != nil {
+	if option := proto.GetExtension(FileDescriptor.Options(fullMethod), options.E_ProxyAuthorization).(*options.Handler); option != nil {
 		proxyAuthorizationValidator = Validator.ProxyAuthorizationValidator(option.Name)
 	}
 
 	if authorizationValidator == nil && proxyAuthorizationValidator == nil {
-		return handler(ctx, req)
+		return ctx, nil
 	}
 
 	var auth, proxyAuth string
@@ -321,6 +271,10 @@ func (s *ServerInterceptor) UnaryInterceptor(ctx context.Context, req interface{
 		proxyAuth = proxyAuthHeader[0]
 	}
 
+	names := strings.Split(fullMethod, "/")
+	serviceName := names[1]
+	methodName := names[2]
+
 	var payload Payload
 	if forwardedByGrpcGateway(meta) {
 		payload = &restPayload{
@@ -338,15 +292,8 @@ func (s *ServerInterceptor) UnaryInterceptor(ctx context.Context, req interface{
 			service:   serviceName,
 			date:      meta.Get(Date)[0],
 			method:    methodName,
-			uri:       info.FullMethod,
-			body: func() string {
-				if req == nil {
-					return ""
-				}
-
-				raw, _ := pbutil.ProtoMessage2JSON(req.(protoV1.Message))
-				return raw
-			}(),
+			uri:       fullMethod,
+			body:      body(),
 		}
 	}
 
@@ -359,34 +306,300 @@ func (s *ServerInterceptor) UnaryInterceptor(ctx context.Context, req interface{
 	}
 
 	if proxyAuthorizationValidator != nil {
-		ok, err := proxyAuthorizationValidator(proxyAuth, payload)
-		if err != nil {
-			return nil, status.Error(codes.PermissionDenied, fmt.Sprintf("%+v", err))
+		if err := verifySignature(proxyAuthorizationValidator, proxyAuth, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	return ctx, nil
+}
+
+func verifySignature(validator signatureHandler, proxyAuth string, payload Payload) error {
+	ok, err := validator(proxyAuth, payload)
+	if err != nil {
+		return status.Error(codes.PermissionDenied, fmt.Sprintf("%+v", err))
+	}
+	if !ok {
+		return status.Error(codes.PermissionDenied, codes.PermissionDenied.String())
+	}
+
+	return nil
+}
+
+// verifyMessage validate the per-message signature of stream by method's proxy_authorization
+func (s *ServerInterceptor) verifyMessage(fullMethod, journalID string, message interface{}, date, proxyAuth string) error {
+	option := proto.GetExtension(FileDescriptor.Options(fullMethod), options.E_ProxyAuthorization).(*options.Handler)
+	if option == nil {
+		return nil
+	}
+
+	validator := Validator.ProxyAuthorizationValidator(option.Name)
+	if validator == nil {
+		return nil
+	}
+
+	raw, err := pbutil.ProtoMessage2JSON(message.(protoV1.Message))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("%+v", err))
+	}
+
+	names := strings.Split(fullMethod, "/")
+	return verifySignature(validator, proxyAuth, &grpcPayload{
+		journalID: journalID,
+		service:   names[1],
+		date:      date,
+		method:    names[2],
+		uri:       fullMethod,
+		body:      raw,
+	})
+}
+
+// UnaryInterceptor a interceptor for server unary operations
+func (s *ServerInterceptor) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	ts := time.Now()
+	journalID := s.journalID(ctx)
+	ctx, span := s.startSpan(ctx, info.FullMethod, journalID)
+
+	doJournal := false
+	if proto.GetExtension(FileDescriptor.Options(info.FullMethod), options.E_Journal).(bool) {
+		doJournal = true
+	}
+
+	defer func() { // double recover for safety
+		if p := recover(); p != nil {
+			s, _ := status.New(codes.Internal, fmt.Sprintf("got double panic => journal_id: %s, error: %+v", journalID, p)).WithDetails(&pb.Stack{Info: string(debug.Stack())})
+			err = s.Err()
+		}
+	}()
+
+	defer func() {
+		if p := recover(); p != nil {
+			s, _ := status.New(codes.Internal, fmt.Sprintf("got panic => journal_id: %s, error: %+v", journalID, p)).WithDetails(&pb.Stack{Info: string(debug.Stack())})
+			err = s.Err()
+		}
+
+		grpc.SetHeader(ctx, metadata.Pairs(runtime.MetadataHeaderPrefix+JournalID, journalID))
+
+		if doJournal {
+			sampling, sampled := sampleJournal(FileDescriptor.JournalSampling(info.FullMethod), err, time.Since(ts))
+			if sampled {
+				incoming, _ := metadata.FromIncomingContext(ctx)
+				journal := s.journal.build(journalID, info.FullMethod, ForwardedByGrpcGateway(ctx), incoming, req, resp, err)
+				journal.Ts = timestamppb.New(ts)
+				journal.CostSeconds = time.Since(ts).Seconds()
+				journal.Sampling = sampling
+
+				s.journal.write(journal)
+			}
+		}
+
+		if s.metrics != nil {
+			s.observe(info.FullMethod, journalID, err, ts)
+		}
+
+		if span != nil {
+			endSpan(span, err)
 		}
-		if !ok {
-			return nil, status.Error(codes.PermissionDenied, codes.PermissionDenied.String())
+	}()
+
+	meta, _ := metadata.FromIncomingContext(ctx)
+	meta.Set(JournalID, journalID)
+	if span != nil {
+		tracing.Inject(meta, span.SpanContext)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, meta)
+
+	if err = validMessage(req); err != nil {
+		return nil, err
+	}
+
+	authorizedCtx, err := s.authorize(ctx, meta, info.FullMethod, journalID, func() string {
+		if req == nil {
+			return ""
 		}
+
+		raw, _ := pbutil.ProtoMessage2JSON(req.(protoV1.Message))
+		return raw
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return handler(ctx, req)
+	return handler(authorizedCtx, req)
 }
 
 type serverWrappedStream struct {
 	grpc.ServerStream
+	ctx context.Context
+
+	// verify not nil when every inbound message signed by client
+	verify func(message interface{}, date, proxyAuthorization string) error
+
+	sync.Mutex
+	journal       *journalBuilder // nil when journal disabled
+	metrics       *Metrics        // nil when prometheus disabled
+	metricsMethod string
+	stream        pb.Stream
+}
+
+func (s *serverWrappedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverWrappedStream) record(direction pb.StreamMessage_Direction, m interface{}) {
+	s.Lock()
+	defer s.Unlock()
+
+	if direction == pb.StreamMessage_INBOUND {
+		s.stream.Received++
+		if s.metrics != nil {
+			s.metrics.StreamReceived.WithLabelValues(s.metricsMethod).Inc()
+		}
+
+	} else {
+		s.stream.Sent++
+		if s.metrics != nil {
+			s.metrics.StreamSent.WithLabelValues(s.metricsMethod).Inc()
+		}
+	}
+
+	if s.journal != nil {
+		s.journal.appendMessage(&s.stream, direction, m)
+	}
 }
 
 func (s *serverWrappedStream) RecvMsg(m interface{}) (err error) {
-	return s.ServerStream.RecvMsg(m)
+	if s.verify == nil {
+		if err = s.ServerStream.RecvMsg(m); err != nil {
+			return
+		}
+
+	} else {
+		envelope := &signedEnvelope{message: m}
+		if err = s.ServerStream.RecvMsg(envelope); err != nil {
+			return
+		}
+
+		if err = s.verify(m, envelope.date, envelope.proxyAuthorization); err != nil {
+			return
+		}
+	}
+
+	s.record(pb.StreamMessage_INBOUND, m)
+	return validMessage(m)
 }
 
 func (s *serverWrappedStream) SendMsg(m interface{}) (err error) {
-	return s.ServerStream.SendMsg(m)
+	if err = s.ServerStream.SendMsg(m); err != nil {
+		return
+	}
+
+	s.record(pb.StreamMessage_OUTBOUND, m)
+	return
 }
 
 // StreamInterceptor a interceptor for server stream operations
 func (s *ServerInterceptor) StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
-	// TODO
-	return errors.New("Not currently supported")
+	ts := time.Now()
+	journalID := s.journalID(stream.Context())
+	ctx, span := s.startSpan(stream.Context(), info.FullMethod, journalID)
+
+	doJournal := false
+	if proto.GetExtension(FileDescriptor.Options(info.FullMethod), options.E_Journal).(bool) {
+		doJournal = true
+	}
+
+	wrappedStream := &serverWrappedStream{
+		ServerStream: stream,
+		ctx:          ctx,
+	}
+	if doJournal {
+		wrappedStream.journal = s.journal
+	}
+
+	if s.metrics != nil {
+		wrappedStream.metrics = s.metrics
+		wrappedStream.metricsMethod = metricsMethod(info.FullMethod)
+		s.metrics.StreamActive.WithLabelValues(wrappedStream.metricsMethod).Inc()
+	}
+
+	defer func() { // double recover for safety
+		if p := recover(); p != nil {
+			s, _ := status.New(codes.Internal, fmt.Sprintf("got double panic => journal_id: %s, error: %+v", journalID, p)).WithDetails(&pb.Stack{Info: string(debug.Stack())})
+			err = s.Err()
+		}
+	}()
+
+	defer func() {
+		if p := recover(); p != nil {
+			s, _ := status.New(codes.Internal, fmt.Sprintf("got panic => journal_id: %s, error: %+v", journalID, p)).WithDetails(&pb.Stack{Info: string(debug.Stack())})
+			err = s.Err()
+		}
+
+		if doJournal {
+			sampling, sampled := sampleJournal(FileDescriptor.JournalSampling(info.FullMethod), err, time.Since(ts))
+			if sampled {
+				incoming, _ := metadata.FromIncomingContext(ctx)
+				journal := s.journal.build(journalID, info.FullMethod, ForwardedByGrpcGateway(ctx), incoming, nil, nil, err)
+				journal.Ts = timestamppb.New(ts)
+				journal.CostSeconds = time.Since(ts).Seconds()
+				journal.Sampling = sampling
+
+				wrappedStream.Lock()
+				journal.Stream = &pb.Stream{
+					Messages: wrappedStream.stream.Messages,
+					Received: wrappedStream.stream.Received,
+					Sent:     wrappedStream.stream.Sent,
+					Dropped:  wrappedStream.stream.Dropped,
+				}
+				wrappedStream.Unlock()
+
+				s.journal.write(journal)
+			}
+		}
+
+		if s.metrics != nil {
+			s.metrics.StreamActive.WithLabelValues(wrappedStream.metricsMethod).Dec()
+			s.metrics.StreamDuration.WithLabelValues(wrappedStream.metricsMethod).Observe(time.Since(ts).Seconds())
+			s.observe(info.FullMethod, journalID, err, ts)
+		}
+
+		if span != nil {
+			wrappedStream.Lock()
+			span.SetAttribute("vv.stream.received", wrappedStream.stream.Received)
+			span.SetAttribute("vv.stream.sent", wrappedStream.stream.Sent)
+			wrappedStream.Unlock()
+
+			endSpan(span, err)
+		}
+	}()
+
+	// the header of stream is sent along with the first message, so set it in advance
+	stream.SetHeader(metadata.Pairs(runtime.MetadataHeaderPrefix+JournalID, journalID))
+
+	meta, _ := metadata.FromIncomingContext(ctx)
+	meta.Set(JournalID, journalID)
+	if span != nil {
+		tracing.Inject(meta, span.SpanContext)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, meta)
+
+	if signedMessages(meta) {
+		wrappedStream.verify = func(message interface{}, date, proxyAuthorization string) error {
+			return s.verifyMessage(info.FullMethod, journalID, message, date, proxyAuthorization)
+		}
+
+	} else if s.requireStreamMessageSign && proto.GetExtension(FileDescriptor.Options(info.FullMethod), options.E_ProxyAuthorization).(*options.Handler) != nil {
+		return status.Error(codes.Unauthenticated, fmt.Sprintf("every message of stream must be signed, content-subtype %s required", SignedCodecName))
+	}
+
+	authorizedCtx, err := s.authorize(ctx, meta, info.FullMethod, journalID, func() string {
+		return "" // there's no request message when the stream opens
+	})
+	if err != nil {
+		return err
+	}
 
-	// return handler(srv, &serverWrappedStream{ServerStream: stream})
+	wrappedStream.ctx = authorizedCtx
+	return handler(srv, wrappedStream)
 }
@@ -0,0 +1,84 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bluekaki/vv/options"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// fakeServerStream the incoming metadata in context, the header discarded
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeServerStream) SetHeader(metadata.MD) error {
+	return nil
+}
+
+func TestRequireStreamMessageSign(t *testing.T) {
+	signed := new(descriptorpb.MethodOptions)
+	proto.SetExtension(signed, options.E_ProxyAuthorization, &options.Handler{Name: "test.stream.sign"})
+
+	FileDescriptor.Lock()
+	FileDescriptor.options["/vv.test.Stream/Signed"] = signed
+	FileDescriptor.options["/vv.test.Stream/Plain"] = new(descriptorpb.MethodOptions)
+	FileDescriptor.Unlock()
+
+	cases := []struct {
+		name        string
+		require     bool
+		fullMethod  string
+		contentType string
+		rejected    bool
+	}{
+		{name: "required, unsigned", require: true, fullMethod: "/vv.test.Stream/Signed", contentType: "application/grpc", rejected: true},
+		{name: "required, no content-type", require: true, fullMethod: "/vv.test.Stream/Signed", rejected: true},
+		{name: "required, signed", require: true, fullMethod: "/vv.test.Stream/Signed", contentType: "application/grpc+" + SignedCodecName},
+		{name: "required, without proxy_authorization", require: true, fullMethod: "/vv.test.Stream/Plain", contentType: "application/grpc"},
+		{name: "not required", fullMethod: "/vv.test.Stream/Signed", contentType: "application/grpc"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			interceptor := NewServerInterceptor(zap.NewNop(), &JournalConfig{}, nil, nil, c.require)
+
+			meta := metadata.MD{}
+			if c.contentType != "" {
+				meta.Set("content-type", c.contentType)
+			}
+			stream := &fakeServerStream{ctx: metadata.NewIncomingContext(context.Background(), meta)}
+
+			handled := false
+			err := interceptor.StreamInterceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: c.fullMethod}, func(interface{}, grpc.ServerStream) error {
+				handled = true
+				return nil
+			})
+
+			if c.rejected {
+				if status.Code(err) != codes.Unauthenticated || !strings.Contains(err.Error(), SignedCodecName) || handled {
+					t.Errorf("want rejected, got %v, handled %v", err, handled)
+				}
+				return
+			}
+
+			if err != nil || !handled {
+				t.Errorf("want handled, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package interceptor
+
+import (
+	"sort"
+	"time"
+
+	"github.com/bluekaki/vv/options"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// serverFaults the codes burn the error budget, the others are caused by client
+var serverFaults = map[codes.Code]bool{
+	codes.Unknown:           true,
+	codes.DeadlineExceeded:  true,
+	codes.ResourceExhausted: true,
+	codes.Unimplemented:     true,
+	codes.Internal:          true,
+	codes.Unavailable:       true,
+	codes.DataLoss:          true,
+}
+
+// objective compiled from options.slo
+type objective struct {
+	latency      time.Duration
+	availability float64
+}
+
+func parseLatencyBuckets(methodOptions protoreflect.ProtoMessage) ([]float64, error) {
+	buckets := proto.GetExtension(methodOptions, options.E_LatencyBuckets).([]float64)
+	if len(buckets) == 0 {
+		return nil, nil
+	}
+
+	if buckets[0] <= 0 || !sort.Float64sAreSorted(buckets) {
+		return nil, errors.Errorf("options.latency_buckets %v must be positive and ascending", buckets)
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] == buckets[i-1] {
+			return nil, errors.Errorf("options.latency_buckets %v duplicated", buckets)
+		}
+	}
+
+	return buckets, nil
+}
+
+func parseObjective(method protoreflect.MethodDescriptor) (*objective, error) {
+	slo := proto.GetExtension(method.Options(), options.E_Slo).(*options.SLO)
+	if slo == nil {
+		return nil, nil
+	}
+
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, errors.New("options.slo works with unary method only")
+	}
+
+	if slo.Availability <= 0 || slo.Availability >= 1 {
+		return nil, errors.Errorf("options.slo availability %v out of (0, 1)", slo.Availability)
+	}
+
+	latency, err := time.ParseDuration(slo.Latency)
+	if err != nil {
+		return nil, errors.Wrap(err, "options.slo latency illegal")
+	}
+	if latency <= 0 {
+		return nil, errors.Errorf("options.slo latency %s must be positive", slo.Latency)
+	}
+
+	return &objective{
+		latency:      latency,
+		availability: slo.Availability,
+	}, nil
+}
+
+// good the call not failed by server, and cost no longer than the latency
+func (o *objective) good(code codes.Code, cost time.Duration) bool {
+	return !serverFaults[code] && cost <= o.latency
+}
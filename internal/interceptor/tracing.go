@@ -0,0 +1,111 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/bluekaki/vv/internal/tracing"
+	"github.com/bluekaki/vv/options"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// startSpan start a span for the rpc, annotated with the vv options of method
+func startSpan(ctx context.Context, tracer *tracing.Tracer, fullMethod string, kind tracing.SpanKind, parent tracing.SpanContext) (context.Context, *tracing.Span) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	ctx, span := tracer.Start(ctx, name, kind, parent)
+
+	span.SetAttribute("rpc.system", "grpc")
+	if index := strings.LastIndex(name, "/"); index > 0 {
+		span.SetAttribute("rpc.service", name[:index])
+		span.SetAttribute("rpc.method", name[index+1:])
+	}
+
+	methodOptions := FileDescriptor.LookupOptions(fullMethod)
+	if methodOptions == nil {
+		return ctx, span
+	}
+
+	span.SetAttribute("vv.journal", proto.GetExtension(methodOptions, options.E_Journal).(bool))
+
+	if option := proto.GetExtension(methodOptions, options.E_Authorization).(*options.Handler); option != nil {
+		span.SetAttribute("vv.authorization", option.Name)
+	}
+
+	if option := proto.GetExtension(methodOptions, options.E_ProxyAuthorization).(*options.Handler); option != nil {
+		span.SetAttribute("vv.proxy_authorization", option.Name)
+	}
+
+	if alias := proto.GetExtension(methodOptions, options.E_MetricsAlias).(string); alias != "" {
+		span.SetAttribute("vv.metrics_alias", alias)
+	}
+
+	return ctx, span
+}
+
+// startClientSpan child of the span in ctx (or the remote one in incoming metadata), and injected into outgoing metadata
+func startClientSpan(ctx context.Context, tracer *tracing.Tracer, fullMethod string) (context.Context, *tracing.Span) {
+	var parent tracing.SpanContext
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		parent = span.SpanContext
+
+	} else {
+		outgoing, _ := metadata.FromOutgoingContext(ctx)
+
+		var ok bool
+		if parent, ok = tracing.Extract(outgoing); !ok {
+			incoming, _ := metadata.FromIncomingContext(ctx)
+			parent, _ = tracing.Extract(incoming)
+		}
+	}
+
+	ctx, span := startSpan(ctx, tracer, fullMethod, tracing.SpanKindClient, parent)
+
+	meta, _ := metadata.FromOutgoingContext(ctx)
+	if meta == nil {
+		meta = make(metadata.MD)
+	}
+
+	if values := meta.Get(JournalID); len(values) > 0 {
+		span.SetAttribute("vv.journal_id", values[0])
+	}
+
+	tracing.Inject(meta, span.SpanContext)
+	return metadata.NewOutgoingContext(ctx, meta), span
+}
+
+// endSpan the span marked as error if err not nil
+func endSpan(span *tracing.Span, err error) {
+	s := status.Convert(err)
+
+	span.SetAttribute("rpc.grpc.status_code", int64(s.Code()))
+	if err != nil {
+		span.SetStatus(tracing.StatusError, s.Message())
+	}
+
+	span.End()
+}
+
+// finishSpan end the span of client stream when the stream finished
+func finishSpan(span *tracing.Span, serverStreams bool, err error) {
+	if finished, err := streamFinished(serverStreams, err); finished {
+		endSpan(span, err)
+	}
+}
+
+// streamFinished the client stream finished when RecvMsg returns io.EOF, any error,
+// or the only response received when the server not streams; the rpc's error returned if finished
+func streamFinished(serverStreams bool, err error) (bool, error) {
+	switch {
+	case err == io.EOF:
+		return true, nil
+
+	case err != nil:
+		return true, err
+	}
+
+	return !serverStreams, nil
+}
@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"sync"
+	"time"
+)
+
+// SpanKind the same value as OpenTelemetry
+type SpanKind int
+
+const (
+	// SpanKindServer a span covering server-side handling of RPC
+	SpanKindServer SpanKind = 2
+	// SpanKindClient a span describing a request to remote service
+	SpanKindClient SpanKind = 3
+)
+
+// StatusCode the same value as OpenTelemetry
+type StatusCode int
+
+const (
+	// StatusUnset the default status
+	StatusUnset StatusCode = 0
+	// StatusOK explicitly succeeded
+	StatusOK StatusCode = 1
+	// StatusError contains an error
+	StatusError StatusCode = 2
+)
+
+// Span a finished span is read-only, it's fields can be read by exporter
+type Span struct {
+	mu     sync.Mutex
+	tracer *Tracer
+	ended  bool
+
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	ParentSpanID  SpanID // invalid if root span
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]interface{} // value is string, bool, int64 or float64
+	StatusCode    StatusCode
+	StatusMessage string
+}
+
+// SetAttribute ignored after span ended
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	switch v := value.(type) {
+	case int:
+		value = int64(v)
+	case int32:
+		value = int64(v)
+	case uint32:
+		value = int64(v)
+	case float32:
+		value = float64(v)
+	}
+
+	s.Attributes[key] = value
+}
+
+// SetStatus ignored after span ended
+func (s *Span) SetStatus(code StatusCode, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ended {
+		return
+	}
+
+	s.StatusCode = code
+	s.StatusMessage = message
+}
+
+// End finish and export the span, only the first call takes effect
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	if s.SpanContext.Sampled() {
+		s.tracer.export(s)
+	}
+}
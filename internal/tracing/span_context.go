@@ -0,0 +1,144 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// Traceparent W3C Trace Context header
+	Traceparent = "traceparent"
+	// Tracestate W3C Trace Context header
+	Tracestate = "tracestate"
+)
+
+// TraceID 16 bytes trace id
+type TraceID [16]byte
+
+// IsValid not all zero
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID 8 bytes span id
+type SpanID [8]byte
+
+// IsValid not all zero
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext the propagated part of span
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte // 0x01 sampled
+	TraceState string
+	Remote     bool // extracted from upstream
+}
+
+// IsValid both trace id and span id valid
+func (s SpanContext) IsValid() bool {
+	return s.TraceID.IsValid() && s.SpanID.IsValid()
+}
+
+// Sampled the sampled flag set
+func (s SpanContext) Sampled() bool {
+	return s.Flags&0x01 == 0x01
+}
+
+// Traceparent format as version 00 traceparent
+func (s SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", s.TraceID, s.SpanID, s.Flags)
+}
+
+// ParseTraceparent parse version-format-traceparent, the unknown future version parsed as 00
+func ParseTraceparent(traceparent string) (SpanContext, bool) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, false
+	}
+
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || len(version) != 1 {
+		return sc, false
+	}
+
+	if len(parts[1]) != 32 || strings.ToLower(parts[1]) != parts[1] {
+		return sc, false
+	}
+	if _, err = hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+
+	if len(parts[2]) != 16 || strings.ToLower(parts[2]) != parts[2] {
+		return sc, false
+	}
+	if _, err = hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return sc, false
+	}
+	sc.Flags = flags[0]
+
+	return sc, sc.IsValid()
+}
+
+// Extract the remote span context from metadata
+func Extract(meta metadata.MD) (SpanContext, bool) {
+	values := meta.Get(Traceparent)
+	if len(values) == 0 {
+		return SpanContext{}, false
+	}
+
+	sc, ok := ParseTraceparent(values[0])
+	if !ok {
+		return SpanContext{}, false
+	}
+
+	if values = meta.Get(Tracestate); len(values) > 0 {
+		sc.TraceState = strings.Join(values, ",")
+	}
+	sc.Remote = true
+
+	return sc, true
+}
+
+// Inject the span context into metadata, overwrite the existing one(s)
+func Inject(meta metadata.MD, sc SpanContext) {
+	meta.Set(Traceparent, sc.Traceparent())
+
+	if sc.TraceState == "" {
+		delete(meta, Tracestate)
+	} else {
+		meta.Set(Tracestate, sc.TraceState)
+	}
+}
+
+func newTraceID() (id TraceID) {
+	io.ReadFull(rand.Reader, id[:])
+	return
+}
+
+func newSpanID() (id SpanID) {
+	io.ReadFull(rand.Reader, id[:])
+	return
+}
@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+
+	cases := []struct {
+		name        string
+		traceparent string
+		ok          bool
+		sampled     bool
+	}{
+		{name: "sampled", traceparent: "00-" + traceID + "-" + spanID + "-01", ok: true, sampled: true},
+		{name: "not sampled", traceparent: "00-" + traceID + "-" + spanID + "-00", ok: true},
+		{name: "surrounding spaces", traceparent: " 00-" + traceID + "-" + spanID + "-01 ", ok: true, sampled: true},
+		{name: "future version with extra field", traceparent: "cc-" + traceID + "-" + spanID + "-01-what-the-future-brings", ok: true, sampled: true},
+		{name: "version 00 with extra field", traceparent: "00-" + traceID + "-" + spanID + "-01-extra"},
+		{name: "version ff", traceparent: "ff-" + traceID + "-" + spanID + "-01"},
+		{name: "version not hex", traceparent: "zz-" + traceID + "-" + spanID + "-01"},
+		{name: "uppercase trace id", traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + spanID + "-01"},
+		{name: "short trace id", traceparent: "00-" + traceID[2:] + "-" + spanID + "-01"},
+		{name: "zero trace id", traceparent: "00-00000000000000000000000000000000-" + spanID + "-01"},
+		{name: "zero span id", traceparent: "00-" + traceID + "-0000000000000000-01"},
+		{name: "span id not hex", traceparent: "00-" + traceID + "-00f067aa0ba902bz-01"},
+		{name: "long flags", traceparent: "00-" + traceID + "-" + spanID + "-001"},
+		{name: "missing field", traceparent: "00-" + traceID + "-" + spanID},
+		{name: "empty"},
+	}
+
+	for _, c := range cases {
+		sc, ok := ParseTraceparent(c.traceparent)
+		if ok != c.ok {
+			t.Errorf("%s: ok %v, want %v", c.name, ok, c.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if sc.TraceID.String() != traceID || sc.SpanID.String() != spanID || sc.Sampled() != c.sampled {
+			t.Errorf("%s: got %+v", c.name, sc)
+		}
+		if c.name != "future version with extra field" && sc.Traceparent() != strings.TrimSpace(c.traceparent) {
+			t.Errorf("%s: formatted as %s", c.name, sc.Traceparent())
+		}
+	}
+}
+
+func TestExtractInject(t *testing.T) {
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	cases := []struct {
+		name       string
+		meta       metadata.MD
+		ok         bool
+		traceState string
+	}{
+		{name: "absent", meta: metadata.MD{}},
+		{name: "malformed", meta: metadata.Pairs(Traceparent, "garbage")},
+		{name: "without tracestate", meta: metadata.Pairs(Traceparent, traceparent), ok: true},
+		{name: "tracestate joined", meta: metadata.Pairs(Traceparent, traceparent, Tracestate, "a=1", Tracestate, "b=2"), ok: true, traceState: "a=1,b=2"},
+	}
+
+	for _, c := range cases {
+		sc, ok := Extract(c.meta)
+		if ok != c.ok {
+			t.Errorf("%s: ok %v, want %v", c.name, ok, c.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if !sc.Remote || sc.TraceState != c.traceState {
+			t.Errorf("%s: got %+v", c.name, sc)
+		}
+
+		meta := metadata.Pairs(Tracestate, "stale=1")
+		Inject(meta, sc)
+		if got := meta.Get(Traceparent); len(got) != 1 || got[0] != traceparent {
+			t.Errorf("%s: injected traceparent %v", c.name, got)
+		}
+
+		got := meta.Get(Tracestate)
+		if (c.traceState == "" && len(got) != 0) || (c.traceState != "" && (len(got) != 1 || got[0] != c.traceState)) {
+			t.Errorf("%s: injected tracestate %v", c.name, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"time"
+)
+
+// Exporter where the finished span(s) exported to
+type Exporter interface {
+	// Export the finished span, should be safe for concurrent use
+	Export(span *Span) error
+	// Close flush and release resource(s)
+	Close() error
+}
+
+// NewTracer create a tracer exports span(s) by exporter
+func NewTracer(exporter Exporter, onError func(error)) *Tracer {
+	return &Tracer{
+		exporter: exporter,
+		onError:  onError,
+	}
+}
+
+// Tracer create span(s)
+type Tracer struct {
+	exporter Exporter
+	onError  func(error)
+}
+
+func (t *Tracer) export(span *Span) {
+	if err := t.exporter.Export(span); err != nil && t.onError != nil {
+		t.onError(err)
+	}
+}
+
+// Start a span as child of parent, a new trace started if parent invalid
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, parent SpanContext) (context.Context, *Span) {
+	span := &Span{
+		tracer:     t,
+		Name:       name,
+		Kind:       kind,
+		StartTime:  time.Now(),
+		Attributes: make(map[string]interface{}),
+	}
+
+	if parent.IsValid() {
+		span.SpanContext = SpanContext{
+			TraceID:    parent.TraceID,
+			SpanID:     newSpanID(),
+			Flags:      parent.Flags,
+			TraceState: parent.TraceState,
+		}
+		span.ParentSpanID = parent.SpanID
+
+	} else {
+		span.SpanContext = SpanContext{
+			TraceID: newTraceID(),
+			SpanID:  newSpanID(),
+			Flags:   0x01,
+		}
+	}
+
+	return ContextWithSpan(ctx, span), span
+}
+
+type spanInContext struct{}
+
+// ContextWithSpan mark span as the current one
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanInContext{}, span)
+}
+
+// SpanFromContext nil if no span in context
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanInContext{}).(*Span)
+	return span
+}
@@ -21,6 +21,113 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Format int32
+
+const (
+	Format_UNKNOWN_FORMAT Format = 0
+	Format_EMAIL          Format = 1
+	Format_URI            Format = 2
+	Format_UUID           Format = 3
+	Format_IP             Format = 4
+)
+
+// Enum value maps for Format.
+var (
+	Format_name = map[int32]string{
+		0: "UNKNOWN_FORMAT",
+		1: "EMAIL",
+		2: "URI",
+		3: "UUID",
+		4: "IP",
+	}
+	Format_value = map[string]int32{
+		"UNKNOWN_FORMAT": 0,
+		"EMAIL":          1,
+		"URI":            2,
+		"UUID":           3,
+		"IP":             4,
+	}
+)
+
+func (x Format) Enum() *Format {
+	p := new(Format)
+	*p = x
+	return p
+}
+
+func (x Format) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Format) Descriptor() protoreflect.EnumDescriptor {
+	return file_options_proto_enumTypes[0].Descriptor()
+}
+
+func (Format) Type() protoreflect.EnumType {
+	return &file_options_proto_enumTypes[0]
+}
+
+func (x Format) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Format.Descriptor instead.
+func (Format) EnumDescriptor() ([]byte, []int) {
+	return file_options_proto_rawDescGZIP(), []int{0}
+}
+
+type Sensitive int32
+
+const (
+	Sensitive_NOT_SENSITIVE Sensitive = 0
+	Sensitive_DROP          Sensitive = 1 // clear the field
+	Sensitive_MASK          Sensitive = 2 // for string: keep at most the last 4 characters, the rest replaced by '*'; others: cleared
+	Sensitive_HASH          Sensitive = 3 // for string & bytes: HMAC-SHA256 keyed by the journal hash key (hex for string); others: cleared
+)
+
+// Enum value maps for Sensitive.
+var (
+	Sensitive_name = map[int32]string{
+		0: "NOT_SENSITIVE",
+		1: "DROP",
+		2: "MASK",
+		3: "HASH",
+	}
+	Sensitive_value = map[string]int32{
+		"NOT_SENSITIVE": 0,
+		"DROP":          1,
+		"MASK":          2,
+		"HASH":          3,
+	}
+)
+
+func (x Sensitive) Enum() *Sensitive {
+	p := new(Sensitive)
+	*p = x
+	return p
+}
+
+func (x Sensitive) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Sensitive) Descriptor() protoreflect.EnumDescriptor {
+	return file_options_proto_enumTypes[1].Descriptor()
+}
+
+func (Sensitive) Type() protoreflect.EnumType {
+	return &file_options_proto_enumTypes[1]
+}
+
+func (x Sensitive) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Sensitive.Descriptor instead.
+func (Sensitive) EnumDescriptor() ([]byte, []int) {
+	return file_options_proto_rawDescGZIP(), []int{1}
+}
+
 type Handler struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -68,6 +175,61 @@ func (x *Handler) GetName() string {
 	return ""
 }
 
+type SLO struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Latency      string  `protobuf:"bytes,1,opt,name=latency,proto3" json:"latency,omitempty"`             // e.g. "300ms", ok calls cost no longer than it are good
+	Availability float64 `protobuf:"fixed64,2,opt,name=availability,proto3" json:"availability,omitempty"` // (0, 1), e.g. 0.999
+}
+
+func (x *SLO) Reset() {
+	*x = SLO{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_options_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SLO) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SLO) ProtoMessage() {}
+
+func (x *SLO) ProtoReflect() protoreflect.Message {
+	mi := &file_options_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SLO.ProtoReflect.Descriptor instead.
+func (*SLO) Descriptor() ([]byte, []int) {
+	return file_options_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SLO) GetLatency() string {
+	if x != nil {
+		return x.Latency
+	}
+	return ""
+}
+
+func (x *SLO) GetAvailability() float64 {
+	if x != nil {
+		return x.Availability
+	}
+	return 0
+}
+
 var file_options_proto_extTypes = []protoimpl.ExtensionInfo{
 	{
 		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
@@ -101,6 +263,38 @@ var file_options_proto_extTypes = []protoimpl.ExtensionInfo{
 		Tag:           "bytes,74373,opt,name=metrics_alias",
 		Filename:      "options.proto",
 	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*float64)(nil),
+		Field:         74394,
+		Name:          "bluekaki.vv.options.journal_sampling",
+		Tag:           "fixed64,74394,opt,name=journal_sampling",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         74395,
+		Name:          "bluekaki.vv.options.journal_slow_threshold",
+		Tag:           "bytes,74395,opt,name=journal_slow_threshold",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: ([]float64)(nil),
+		Field:         74396,
+		Name:          "bluekaki.vv.options.latency_buckets",
+		Tag:           "fixed64,74396,rep,name=latency_buckets",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*SLO)(nil),
+		Field:         74397,
+		Name:          "bluekaki.vv.options.slo",
+		Tag:           "bytes,74397,opt,name=slo",
+		Filename:      "options.proto",
+	},
 	{
 		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
 		ExtensionType: (*bool)(nil),
@@ -157,6 +351,110 @@ var file_options_proto_extTypes = []protoimpl.ExtensionInfo{
 		Tag:           "bytes,74380,opt,name=ge",
 		Filename:      "options.proto",
 	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         74381,
+		Name:          "bluekaki.vv.options.pattern",
+		Tag:           "bytes,74381,opt,name=pattern",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*uint64)(nil),
+		Field:         74382,
+		Name:          "bluekaki.vv.options.min_len",
+		Tag:           "varint,74382,opt,name=min_len",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*uint64)(nil),
+		Field:         74383,
+		Name:          "bluekaki.vv.options.max_len",
+		Tag:           "varint,74383,opt,name=max_len",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*uint64)(nil),
+		Field:         74384,
+		Name:          "bluekaki.vv.options.min_items",
+		Tag:           "varint,74384,opt,name=min_items",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*uint64)(nil),
+		Field:         74385,
+		Name:          "bluekaki.vv.options.max_items",
+		Tag:           "varint,74385,opt,name=max_items",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: ([]string)(nil),
+		Field:         74386,
+		Name:          "bluekaki.vv.options.in",
+		Tag:           "bytes,74386,rep,name=in",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: ([]string)(nil),
+		Field:         74387,
+		Name:          "bluekaki.vv.options.not_in",
+		Tag:           "bytes,74387,rep,name=not_in",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*bool)(nil),
+		Field:         74388,
+		Name:          "bluekaki.vv.options.defined_only",
+		Tag:           "varint,74388,opt,name=defined_only",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*Format)(nil),
+		Field:         74389,
+		Name:          "bluekaki.vv.options.format",
+		Tag:           "varint,74389,opt,name=format,enum=bluekaki.vv.options.Format",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*bool)(nil),
+		Field:         74390,
+		Name:          "bluekaki.vv.options.lt_now",
+		Tag:           "varint,74390,opt,name=lt_now",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*bool)(nil),
+		Field:         74391,
+		Name:          "bluekaki.vv.options.gt_now",
+		Tag:           "varint,74391,opt,name=gt_now",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*Sensitive)(nil),
+		Field:         74393,
+		Name:          "bluekaki.vv.options.sensitive",
+		Tag:           "varint,74393,opt,name=sensitive,enum=bluekaki.vv.options.Sensitive",
+		Filename:      "options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.OneofOptions)(nil),
+		ExtensionType: (*bool)(nil),
+		Field:         74392,
+		Name:          "bluekaki.vv.options.required",
+		Tag:           "varint,74392,opt,name=required",
+		Filename:      "options.proto",
+	},
 }
 
 // Extension fields to descriptorpb.MethodOptions.
@@ -169,6 +467,22 @@ var (
 	E_ProxyAuthorization = &file_options_proto_extTypes[2]
 	// optional string metrics_alias = 74373;
 	E_MetricsAlias = &file_options_proto_extTypes[3]
+	// works with journal, errors and slow calls are always journaled, successful fast calls journaled at this rate [0, 1]
+	//
+	// optional double journal_sampling = 74394;
+	E_JournalSampling = &file_options_proto_extTypes[4]
+	// works with journal_sampling, calls cost longer than it (e.g. "500ms") are slow calls
+	//
+	// optional string journal_slow_threshold = 74395;
+	E_JournalSlowThreshold = &file_options_proto_extTypes[5]
+	// the buckets (seconds, ascending) of request cost histograms, instead of the default 0.1 to 1.1
+	//
+	// repeated double latency_buckets = 74396;
+	E_LatencyBuckets = &file_options_proto_extTypes[6]
+	// the objective of unary method, exported as good/total counters
+	//
+	// optional bluekaki.vv.options.SLO slo = 74397;
+	E_Slo = &file_options_proto_extTypes[7]
 )
 
 // Extension fields to descriptorpb.FieldOptions.
@@ -176,19 +490,49 @@ var (
 	// for string: not empty; numeric: not zero; bytes: not nil; map: not nil
 	//
 	// optional bool require = 74374;
-	E_Require = &file_options_proto_extTypes[4]
+	E_Require = &file_options_proto_extTypes[8]
 	// optional string eq = 74375;
-	E_Eq = &file_options_proto_extTypes[5] // equal to
+	E_Eq = &file_options_proto_extTypes[9] // equal to
 	// optional string ne = 74376;
-	E_Ne = &file_options_proto_extTypes[6] // not equal to
+	E_Ne = &file_options_proto_extTypes[10] // not equal to
 	// optional string lt = 74377;
-	E_Lt = &file_options_proto_extTypes[7] // less then
+	E_Lt = &file_options_proto_extTypes[11] // less then
 	// optional string le = 74378;
-	E_Le = &file_options_proto_extTypes[8] // less than or equal to
+	E_Le = &file_options_proto_extTypes[12] // less than or equal to
 	// optional string gt = 74379;
-	E_Gt = &file_options_proto_extTypes[9] // greater than
+	E_Gt = &file_options_proto_extTypes[13] // greater than
 	// optional string ge = 74380;
-	E_Ge = &file_options_proto_extTypes[10] // greater than or equal to
+	E_Ge = &file_options_proto_extTypes[14] // greater than or equal to
+	// optional string pattern = 74381;
+	E_Pattern = &file_options_proto_extTypes[15] // for string: RE2 regular expression
+	// optional uint64 min_len = 74382;
+	E_MinLen = &file_options_proto_extTypes[16] // for string: count of runes; bytes: count of bytes
+	// optional uint64 max_len = 74383;
+	E_MaxLen = &file_options_proto_extTypes[17] // for string: count of runes; bytes: count of bytes
+	// optional uint64 min_items = 74384;
+	E_MinItems = &file_options_proto_extTypes[18] // for repeated & map
+	// optional uint64 max_items = 74385;
+	E_MaxItems = &file_options_proto_extTypes[19] // for repeated & map
+	// repeated string in = 74386;
+	E_In = &file_options_proto_extTypes[20] // in the set
+	// repeated string not_in = 74387;
+	E_NotIn = &file_options_proto_extTypes[21] // not in the set
+	// optional bool defined_only = 74388;
+	E_DefinedOnly = &file_options_proto_extTypes[22] // for enum: one of the defined values
+	// optional bluekaki.vv.options.Format format = 74389;
+	E_Format = &file_options_proto_extTypes[23] // for string: well-known format
+	// optional bool lt_now = 74390;
+	E_LtNow = &file_options_proto_extTypes[24] // for google.protobuf.Timestamp: before now
+	// optional bool gt_now = 74391;
+	E_GtNow = &file_options_proto_extTypes[25] // for google.protobuf.Timestamp: after now
+	// optional bluekaki.vv.options.Sensitive sensitive = 74393;
+	E_Sensitive = &file_options_proto_extTypes[26] // redact it in journal
+)
+
+// Extension fields to descriptorpb.OneofOptions.
+var (
+	// optional bool required = 74392;
+	E_Required = &file_options_proto_extTypes[27] // one of the fields must be set
 )
 
 var File_options_proto protoreflect.FileDescriptor
@@ -200,55 +544,142 @@ var file_options_proto_rawDesc = []byte{
 	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72,
 	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x1d, 0x0a, 0x07, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65,
 	0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x3a, 0x3d, 0x0a, 0x07, 0x6a, 0x6f, 0x75, 0x72, 0x6e, 0x61, 0x6c,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x43, 0x0a, 0x03, 0x53, 0x4c, 0x4f, 0x12, 0x18, 0x0a, 0x07,
+	0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6c,
+	0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x22, 0x0a, 0x0c, 0x61, 0x76, 0x61, 0x69, 0x6c, 0x61,
+	0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c, 0x61, 0x76,
+	0x61, 0x69, 0x6c, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x2a, 0x42, 0x0a, 0x06, 0x46, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x12, 0x12, 0x0a, 0x0e, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x5f,
+	0x46, 0x4f, 0x52, 0x4d, 0x41, 0x54, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x4d, 0x41, 0x49,
+	0x4c, 0x10, 0x01, 0x12, 0x07, 0x0a, 0x03, 0x55, 0x52, 0x49, 0x10, 0x02, 0x12, 0x08, 0x0a, 0x04,
+	0x55, 0x55, 0x49, 0x44, 0x10, 0x03, 0x12, 0x06, 0x0a, 0x02, 0x49, 0x50, 0x10, 0x04, 0x2a, 0x3c,
+	0x0a, 0x09, 0x53, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x69, 0x76, 0x65, 0x12, 0x11, 0x0a, 0x0d, 0x4e,
+	0x4f, 0x54, 0x5f, 0x53, 0x45, 0x4e, 0x53, 0x49, 0x54, 0x49, 0x56, 0x45, 0x10, 0x00, 0x12, 0x08,
+	0x0a, 0x04, 0x44, 0x52, 0x4f, 0x50, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x4d, 0x41, 0x53, 0x4b,
+	0x10, 0x02, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x41, 0x53, 0x48, 0x10, 0x03, 0x3a, 0x3d, 0x0a, 0x07,
+	0x6a, 0x6f, 0x75, 0x72, 0x6e, 0x61, 0x6c, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64,
+	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x82, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x07, 0x6a, 0x6f, 0x75, 0x72, 0x6e, 0x61, 0x6c, 0x88, 0x01, 0x01, 0x3a, 0x67, 0x0a, 0x0d, 0x61,
+	0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d,
+	0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x83, 0xc5, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x62, 0x6c, 0x75, 0x65, 0x6b, 0x61, 0x6b, 0x69, 0x2e,
+	0x76, 0x76, 0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x48, 0x61, 0x6e, 0x64, 0x6c,
+	0x65, 0x72, 0x52, 0x0d, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x88, 0x01, 0x01, 0x3a, 0x72, 0x0a, 0x13, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x5f, 0x61, 0x75,
+	0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65,
+	0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x84, 0xc5, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x62, 0x6c, 0x75, 0x65, 0x6b, 0x61, 0x6b, 0x69, 0x2e, 0x76,
+	0x76, 0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65,
+	0x72, 0x52, 0x12, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x3a, 0x48, 0x0a, 0x0d, 0x6d, 0x65, 0x74, 0x72,
+	0x69, 0x63, 0x73, 0x5f, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68,
+	0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x85, 0xc5, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0c, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x41, 0x6c, 0x69, 0x61, 0x73, 0x88,
+	0x01, 0x01, 0x3a, 0x4e, 0x0a, 0x10, 0x6a, 0x6f, 0x75, 0x72, 0x6e, 0x61, 0x6c, 0x5f, 0x73, 0x61,
+	0x6d, 0x70, 0x6c, 0x69, 0x6e, 0x67, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x9a, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0f,
+	0x6a, 0x6f, 0x75, 0x72, 0x6e, 0x61, 0x6c, 0x53, 0x61, 0x6d, 0x70, 0x6c, 0x69, 0x6e, 0x67, 0x88,
+	0x01, 0x01, 0x3a, 0x59, 0x0a, 0x16, 0x6a, 0x6f, 0x75, 0x72, 0x6e, 0x61, 0x6c, 0x5f, 0x73, 0x6c,
+	0x6f, 0x77, 0x5f, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x12, 0x1e, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d,
+	0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x9b, 0xc5, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x14, 0x6a, 0x6f, 0x75, 0x72, 0x6e, 0x61, 0x6c, 0x53, 0x6c, 0x6f,
+	0x77, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x88, 0x01, 0x01, 0x3a, 0x49, 0x0a,
+	0x0f, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73,
 	0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
 	0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x18, 0x82, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x6a, 0x6f, 0x75, 0x72, 0x6e, 0x61,
-	0x6c, 0x88, 0x01, 0x01, 0x3a, 0x67, 0x0a, 0x0d, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x83, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
-	0x62, 0x6c, 0x75, 0x65, 0x6b, 0x61, 0x6b, 0x69, 0x2e, 0x76, 0x76, 0x2e, 0x6f, 0x70, 0x74, 0x69,
-	0x6f, 0x6e, 0x73, 0x2e, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x72, 0x52, 0x0d, 0x61, 0x75, 0x74,
-	0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x3a, 0x72, 0x0a,
-	0x13, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x5f, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61,
-	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74,
-	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x84, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x62,
-	0x6c, 0x75, 0x65, 0x6b, 0x61, 0x6b, 0x69, 0x2e, 0x76, 0x76, 0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x2e, 0x48, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x72, 0x52, 0x12, 0x70, 0x72, 0x6f, 0x78,
-	0x79, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01,
-	0x01, 0x3a, 0x48, 0x0a, 0x0d, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x5f, 0x61, 0x6c, 0x69,
-	0x61, 0x73, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x18, 0x85, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x73, 0x41, 0x6c, 0x69, 0x61, 0x73, 0x88, 0x01, 0x01, 0x3a, 0x3c, 0x0a, 0x07, 0x72,
-	0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x86, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72,
-	0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x88, 0x01, 0x01, 0x3a, 0x32, 0x0a, 0x02, 0x65, 0x71, 0x12,
+	0x18, 0x9c, 0xc5, 0x04, 0x20, 0x03, 0x28, 0x01, 0x52, 0x0e, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63,
+	0x79, 0x42, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x3a, 0x4f, 0x0a, 0x03, 0x73, 0x6c, 0x6f, 0x12,
+	0x1e, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x9d, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x62, 0x6c, 0x75, 0x65, 0x6b, 0x61,
+	0x6b, 0x69, 0x2e, 0x76, 0x76, 0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x53, 0x4c,
+	0x4f, 0x52, 0x03, 0x73, 0x6c, 0x6f, 0x88, 0x01, 0x01, 0x3a, 0x3c, 0x0a, 0x07, 0x72, 0x65, 0x71,
+	0x75, 0x69, 0x72, 0x65, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x86, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x65, 0x71,
+	0x75, 0x69, 0x72, 0x65, 0x88, 0x01, 0x01, 0x3a, 0x32, 0x0a, 0x02, 0x65, 0x71, 0x12, 0x1d, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x87, 0xc5, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x65, 0x71, 0x88, 0x01, 0x01, 0x3a, 0x32, 0x0a, 0x02, 0x6e,
+	0x65, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x88, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x6e, 0x65, 0x88, 0x01, 0x01, 0x3a,
+	0x32, 0x0a, 0x02, 0x6c, 0x74, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x89, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x6c, 0x74,
+	0x88, 0x01, 0x01, 0x3a, 0x32, 0x0a, 0x02, 0x6c, 0x65, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c,
+	0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x8a, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x6c, 0x65, 0x88, 0x01, 0x01, 0x3a, 0x32, 0x0a, 0x02, 0x67, 0x74, 0x12, 0x1d, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x8b, 0xc5, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x67, 0x74, 0x88, 0x01, 0x01, 0x3a, 0x32, 0x0a, 0x02, 0x67,
+	0x65, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x8c, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x67, 0x65, 0x88, 0x01, 0x01, 0x3a,
+	0x3c, 0x0a, 0x07, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65,
+	0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x8d, 0xc5, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x88, 0x01, 0x01, 0x3a, 0x3b, 0x0a,
+	0x07, 0x6d, 0x69, 0x6e, 0x5f, 0x6c, 0x65, 0x6e, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64,
+	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x8e, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x06, 0x6d, 0x69, 0x6e, 0x4c, 0x65, 0x6e, 0x88, 0x01, 0x01, 0x3a, 0x3b, 0x0a, 0x07, 0x6d, 0x61,
+	0x78, 0x5f, 0x6c, 0x65, 0x6e, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x8f, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x6d, 0x61,
+	0x78, 0x4c, 0x65, 0x6e, 0x88, 0x01, 0x01, 0x3a, 0x3f, 0x0a, 0x09, 0x6d, 0x69, 0x6e, 0x5f, 0x69,
+	0x74, 0x65, 0x6d, 0x73, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x90, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x6d, 0x69, 0x6e,
+	0x49, 0x74, 0x65, 0x6d, 0x73, 0x88, 0x01, 0x01, 0x3a, 0x3f, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f,
+	0x69, 0x74, 0x65, 0x6d, 0x73, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x91, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x6d, 0x61,
+	0x78, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x88, 0x01, 0x01, 0x3a, 0x2f, 0x0a, 0x02, 0x69, 0x6e, 0x12,
 	0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
-	0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x87,
-	0xc5, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x65, 0x71, 0x88, 0x01, 0x01, 0x3a, 0x32, 0x0a,
-	0x02, 0x6e, 0x65, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x92,
+	0xc5, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x02, 0x69, 0x6e, 0x3a, 0x36, 0x0a, 0x06, 0x6e, 0x6f,
+	0x74, 0x5f, 0x69, 0x6e, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x93, 0xc5, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x6f, 0x74,
+	0x49, 0x6e, 0x3a, 0x45, 0x0a, 0x0c, 0x64, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x64, 0x5f, 0x6f, 0x6e,
+	0x6c, 0x79, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0x94, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x64, 0x65, 0x66, 0x69, 0x6e,
+	0x65, 0x64, 0x4f, 0x6e, 0x6c, 0x79, 0x88, 0x01, 0x01, 0x3a, 0x57, 0x0a, 0x06, 0x66, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
 	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x18, 0x88, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x6e, 0x65, 0x88, 0x01,
-	0x01, 0x3a, 0x32, 0x0a, 0x02, 0x6c, 0x74, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x6e, 0x73, 0x18, 0x95, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1b, 0x2e, 0x62, 0x6c, 0x75,
+	0x65, 0x6b, 0x61, 0x6b, 0x69, 0x2e, 0x76, 0x76, 0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x2e, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x88,
+	0x01, 0x01, 0x3a, 0x39, 0x0a, 0x06, 0x6c, 0x74, 0x5f, 0x6e, 0x6f, 0x77, 0x12, 0x1d, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46,
+	0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x96, 0xc5, 0x04, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x05, 0x6c, 0x74, 0x4e, 0x6f, 0x77, 0x88, 0x01, 0x01, 0x3a, 0x39, 0x0a,
+	0x06, 0x67, 0x74, 0x5f, 0x6e, 0x6f, 0x77, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
 	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f,
-	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x89, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02,
-	0x6c, 0x74, 0x88, 0x01, 0x01, 0x3a, 0x32, 0x0a, 0x02, 0x6c, 0x65, 0x12, 0x1d, 0x2e, 0x67, 0x6f,
-	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69,
-	0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x8a, 0xc5, 0x04, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x02, 0x6c, 0x65, 0x88, 0x01, 0x01, 0x3a, 0x32, 0x0a, 0x02, 0x67, 0x74, 0x12,
-	0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
-	0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x8b,
-	0xc5, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x67, 0x74, 0x88, 0x01, 0x01, 0x3a, 0x32, 0x0a,
-	0x02, 0x67, 0x65, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x18, 0x8c, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x67, 0x65, 0x88, 0x01,
-	0x01, 0x42, 0x20, 0x5a, 0x1e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
-	0x62, 0x6c, 0x75, 0x65, 0x6b, 0x61, 0x6b, 0x69, 0x2f, 0x76, 0x76, 0x2f, 0x6f, 0x70, 0x74, 0x69,
-	0x6f, 0x6e, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x97, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05,
+	0x67, 0x74, 0x4e, 0x6f, 0x77, 0x88, 0x01, 0x01, 0x3a, 0x60, 0x0a, 0x09, 0x73, 0x65, 0x6e, 0x73,
+	0x69, 0x74, 0x69, 0x76, 0x65, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x99, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x62,
+	0x6c, 0x75, 0x65, 0x6b, 0x61, 0x6b, 0x69, 0x2e, 0x76, 0x76, 0x2e, 0x6f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x2e, 0x53, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x69, 0x76, 0x65, 0x52, 0x09, 0x73, 0x65,
+	0x6e, 0x73, 0x69, 0x74, 0x69, 0x76, 0x65, 0x88, 0x01, 0x01, 0x3a, 0x3e, 0x0a, 0x08, 0x72, 0x65,
+	0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x12, 0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4f, 0x6e, 0x65, 0x6f, 0x66, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x98, 0xc5, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x72,
+	0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x88, 0x01, 0x01, 0x42, 0x20, 0x5a, 0x1e, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x62, 0x6c, 0x75, 0x65, 0x6b, 0x61, 0x6b,
+	0x69, 0x2f, 0x76, 0x76, 0x2f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -263,30 +694,55 @@ func file_options_proto_rawDescGZIP() []byte {
 	return file_options_proto_rawDescData
 }
 
-var file_options_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_options_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_options_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_options_proto_goTypes = []interface{}{
-	(*Handler)(nil),                    // 0: bluekaki.vv.options.Handler
-	(*descriptorpb.MethodOptions)(nil), // 1: google.protobuf.MethodOptions
-	(*descriptorpb.FieldOptions)(nil),  // 2: google.protobuf.FieldOptions
+	(Format)(0),                        // 0: bluekaki.vv.options.Format
+	(Sensitive)(0),                     // 1: bluekaki.vv.options.Sensitive
+	(*Handler)(nil),                    // 2: bluekaki.vv.options.Handler
+	(*SLO)(nil),                        // 3: bluekaki.vv.options.SLO
+	(*descriptorpb.MethodOptions)(nil), // 4: google.protobuf.MethodOptions
+	(*descriptorpb.FieldOptions)(nil),  // 5: google.protobuf.FieldOptions
+	(*descriptorpb.OneofOptions)(nil),  // 6: google.protobuf.OneofOptions
 }
 var file_options_proto_depIdxs = []int32{
-	1,  // 0: bluekaki.vv.options.journal:extendee -> google.protobuf.MethodOptions
-	1,  // 1: bluekaki.vv.options.authorization:extendee -> google.protobuf.MethodOptions
-	1,  // 2: bluekaki.vv.options.proxy_authorization:extendee -> google.protobuf.MethodOptions
-	1,  // 3: bluekaki.vv.options.metrics_alias:extendee -> google.protobuf.MethodOptions
-	2,  // 4: bluekaki.vv.options.require:extendee -> google.protobuf.FieldOptions
-	2,  // 5: bluekaki.vv.options.eq:extendee -> google.protobuf.FieldOptions
-	2,  // 6: bluekaki.vv.options.ne:extendee -> google.protobuf.FieldOptions
-	2,  // 7: bluekaki.vv.options.lt:extendee -> google.protobuf.FieldOptions
-	2,  // 8: bluekaki.vv.options.le:extendee -> google.protobuf.FieldOptions
-	2,  // 9: bluekaki.vv.options.gt:extendee -> google.protobuf.FieldOptions
-	2,  // 10: bluekaki.vv.options.ge:extendee -> google.protobuf.FieldOptions
-	0,  // 11: bluekaki.vv.options.authorization:type_name -> bluekaki.vv.options.Handler
-	0,  // 12: bluekaki.vv.options.proxy_authorization:type_name -> bluekaki.vv.options.Handler
-	13, // [13:13] is the sub-list for method output_type
-	13, // [13:13] is the sub-list for method input_type
-	11, // [11:13] is the sub-list for extension type_name
-	0,  // [0:11] is the sub-list for extension extendee
+	4,  // 0: bluekaki.vv.options.journal:extendee -> google.protobuf.MethodOptions
+	4,  // 1: bluekaki.vv.options.authorization:extendee -> google.protobuf.MethodOptions
+	4,  // 2: bluekaki.vv.options.proxy_authorization:extendee -> google.protobuf.MethodOptions
+	4,  // 3: bluekaki.vv.options.metrics_alias:extendee -> google.protobuf.MethodOptions
+	4,  // 4: bluekaki.vv.options.journal_sampling:extendee -> google.protobuf.MethodOptions
+	4,  // 5: bluekaki.vv.options.journal_slow_threshold:extendee -> google.protobuf.MethodOptions
+	4,  // 6: bluekaki.vv.options.latency_buckets:extendee -> google.protobuf.MethodOptions
+	4,  // 7: bluekaki.vv.options.slo:extendee -> google.protobuf.MethodOptions
+	5,  // 8: bluekaki.vv.options.require:extendee -> google.protobuf.FieldOptions
+	5,  // 9: bluekaki.vv.options.eq:extendee -> google.protobuf.FieldOptions
+	5,  // 10: bluekaki.vv.options.ne:extendee -> google.protobuf.FieldOptions
+	5,  // 11: bluekaki.vv.options.lt:extendee -> google.protobuf.FieldOptions
+	5,  // 12: bluekaki.vv.options.le:extendee -> google.protobuf.FieldOptions
+	5,  // 13: bluekaki.vv.options.gt:extendee -> google.protobuf.FieldOptions
+	5,  // 14: bluekaki.vv.options.ge:extendee -> google.protobuf.FieldOptions
+	5,  // 15: bluekaki.vv.options.pattern:extendee -> google.protobuf.FieldOptions
+	5,  // 16: bluekaki.vv.options.min_len:extendee -> google.protobuf.FieldOptions
+	5,  // 17: bluekaki.vv.options.max_len:extendee -> google.protobuf.FieldOptions
+	5,  // 18: bluekaki.vv.options.min_items:extendee -> google.protobuf.FieldOptions
+	5,  // 19: bluekaki.vv.options.max_items:extendee -> google.protobuf.FieldOptions
+	5,  // 20: bluekaki.vv.options.in:extendee -> google.protobuf.FieldOptions
+	5,  // 21: bluekaki.vv.options.not_in:extendee -> google.protobuf.FieldOptions
+	5,  // 22: bluekaki.vv.options.defined_only:extendee -> google.protobuf.FieldOptions
+	5,  // 23: bluekaki.vv.options.format:extendee -> google.protobuf.FieldOptions
+	5,  // 24: bluekaki.vv.options.lt_now:extendee -> google.protobuf.FieldOptions
+	5,  // 25: bluekaki.vv.options.gt_now:extendee -> google.protobuf.FieldOptions
+	5,  // 26: bluekaki.vv.options.sensitive:extendee -> google.protobuf.FieldOptions
+	6,  // 27: bluekaki.vv.options.required:extendee -> google.protobuf.OneofOptions
+	2,  // 28: bluekaki.vv.options.authorization:type_name -> bluekaki.vv.options.Handler
+	2,  // 29: bluekaki.vv.options.proxy_authorization:type_name -> bluekaki.vv.options.Handler
+	3,  // 30: bluekaki.vv.options.slo:type_name -> bluekaki.vv.options.SLO
+	0,  // 31: bluekaki.vv.options.format:type_name -> bluekaki.vv.options.Format
+	1,  // 32: bluekaki.vv.options.sensitive:type_name -> bluekaki.vv.options.Sensitive
+	33, // [33:33] is the sub-list for method output_type
+	33, // [33:33] is the sub-list for method input_type
+	28, // [28:33] is the sub-list for extension type_name
+	0,  // [0:28] is the sub-list for extension extendee
 	0,  // [0:0] is the sub-list for field type_name
 }
 
@@ -308,19 +764,32 @@ func file_options_proto_init() {
 				return nil
 			}
 		}
+		file_options_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SLO); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_options_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   1,
-			NumExtensions: 11,
+			NumEnums:      2,
+			NumMessages:   2,
+			NumExtensions: 28,
 			NumServices:   0,
 		},
 		GoTypes:           file_options_proto_goTypes,
 		DependencyIndexes: file_options_proto_depIdxs,
+		EnumInfos:         file_options_proto_enumTypes,
 		MessageInfos:      file_options_proto_msgTypes,
 		ExtensionInfos:    file_options_proto_extTypes,
 	}.Build()
@@ -11,7 +11,7 @@ import (
 )
 
 func newGateway(ctx context.Context, grpcAddr, restAddr string) *http.Server {
-	mux, options := gateway.New()
+	mux, options := gateway.New(gateway.WithHealthz(ctx, grpcAddr))
 	if err := pb.RegisterDummyServiceHandlerFromEndpoint(ctx, mux, grpcAddr, options); err != nil {
 		logger.Fatal("register gateway err", zap.Error(err))
 	}
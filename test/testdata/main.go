@@ -114,15 +114,15 @@ func serverCmd() *cobra.Command {
 
 	var (
 		serverAddr      string
-		prometheusAddr  string
+		adminAddr       string
 		pushgatewayAddr string
 	)
 	cmd.Flags().StringVar(&serverAddr, "server", "", "server addr")
-	cmd.Flags().StringVar(&prometheusAddr, "prometheus", "", "prometheus addr")
+	cmd.Flags().StringVar(&adminAddr, "admin", "", "admin addr, serves metrics, health & pprof")
 	cmd.Flags().StringVar(&pushgatewayAddr, "pushgateway", "", "pushgateway addr")
 
 	cmd.Run = func(cmd *cobra.Command, args []string) {
-		server := newServer(serverAddr, prometheusAddr, pushgatewayAddr)
+		server := newServer(serverAddr, adminAddr, pushgatewayAddr)
 		shutdown.NewHook().Close(
 			func() {
 				server.GracefulStop()
@@ -40,16 +40,16 @@ func init() {
 	})
 }
 
-func newServer(grpcAddr, prometheusAddr, pushgatewayAddr string) *grpc.Server {
-	var opt vvs.Option
-	if prometheusAddr != "" {
-		opt = vvs.WithPrometheus(prometheusAddr)
+func newServer(grpcAddr, adminAddr, pushgatewayAddr string) *vvs.Server {
+	var options []vvs.Option
+	if adminAddr != "" {
+		options = append(options, vvs.WithAdmin(adminAddr))
 	}
 	if pushgatewayAddr != "" {
-		opt = vvs.WithPrometheusPush(pushgatewayAddr)
+		options = append(options, vvs.WithPrometheusPush(pushgatewayAddr))
 	}
 
-	server, err := vvs.New(logger, opt)
+	server, err := vvs.New(logger, options...)
 	if err != nil {
 		logger.Fatal("new server err", zap.Error(err))
 	}